@@ -0,0 +1,267 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// CallHierarchyProvider exposes the data flow between the properties of a
+// component. "a <= b" makes a call b, "=>" makes the owner property call the
+// child one, a property creating an instance calls its overrides, and
+// TypeScript methods call every "this.x()" they contain. Overrides are named
+// by their path, e.g. "Selector.value".
+type CallHierarchyProvider struct {
+	projectScanner *ProjectScanner
+	typeInferrer   *TypeInferrer
+}
+
+func NewCallHierarchyProvider(projectScanner *ProjectScanner) *CallHierarchyProvider {
+	return &CallHierarchyProvider{
+		projectScanner: projectScanner,
+		typeInferrer:   NewTypeInferrer(projectScanner),
+	}
+}
+
+// callEdge is one call between two properties of a component
+type callEdge struct {
+	caller       string
+	callerSource string
+	callee       string
+	site         Range // in the file of the caller
+}
+
+// PrepareCallHierarchy returns the property at the position
+func (cp *CallHierarchyProvider) PrepareCallHierarchy(document *TextDocument, position Position) []CallHierarchyItem {
+	items := []CallHierarchyItem{}
+	tree := ParseTree(document.Text)
+	decls := BuildComponentDecls(tree, uriToPath(document.URI))
+	node := tree.NodeAt(position)
+	decl := ComponentAt(decls, position.Line)
+	if node == nil || decl == nil || node.IsData() || !isPropertyName(node.Name) ||
+		(node.Parent != nil && strings.HasPrefix(node.Parent.Name, "*")) {
+		return items
+	}
+
+	inferrer := cp.typeInferrer.ForDocument(decls)
+	if item := cp.item(inferrer, decl.Name, cp.propertyPath(decl, node), HierarchySourceTree); item != nil {
+		items = append(items, *item)
+	}
+	return items
+}
+
+// IncomingCalls returns the properties and methods calling the item
+func (cp *CallHierarchyProvider) IncomingCalls(item CallHierarchyItem) []CallHierarchyIncomingCall {
+	calls := []CallHierarchyIncomingCall{}
+	if item.Data == nil {
+		return calls
+	}
+	component := item.Data.Component
+
+	index := make(map[string]int)
+	for _, edge := range cp.edges(component) {
+		if edge.callee != item.Data.Property {
+			continue
+		}
+		key := edge.callerSource + ":" + edge.caller
+		if position, ok := index[key]; ok {
+			calls[position].FromRanges = append(calls[position].FromRanges, edge.site)
+			continue
+		}
+		from := cp.item(cp.typeInferrer, component, edge.caller, edge.callerSource)
+		if from == nil {
+			continue
+		}
+		index[key] = len(calls)
+		calls = append(calls, CallHierarchyIncomingCall{From: *from, FromRanges: []Range{edge.site}})
+	}
+	return calls
+}
+
+// OutgoingCalls returns the properties the item calls
+func (cp *CallHierarchyProvider) OutgoingCalls(item CallHierarchyItem) []CallHierarchyOutgoingCall {
+	calls := []CallHierarchyOutgoingCall{}
+	if item.Data == nil {
+		return calls
+	}
+	component := item.Data.Component
+
+	index := make(map[string]int)
+	for _, edge := range cp.edges(component) {
+		if edge.caller != item.Data.Property || edge.callerSource != item.Data.Source {
+			continue
+		}
+		if position, ok := index[edge.callee]; ok {
+			calls[position].FromRanges = append(calls[position].FromRanges, edge.site)
+			continue
+		}
+		to := cp.item(cp.typeInferrer, component, edge.callee, "")
+		if to == nil {
+			continue
+		}
+		index[edge.callee] = len(calls)
+		calls = append(calls, CallHierarchyOutgoingCall{To: *to, FromRanges: []Range{edge.site}})
+	}
+	return calls
+}
+
+// edges builds the call graph of a component from its view.tree declaration
+// and its TypeScript class
+func (cp *CallHierarchyProvider) edges(component string) []callEdge {
+	var edges []callEdge
+
+	if decl := cp.projectScanner.GetComponentDecl(component); decl != nil {
+		for _, binding := range decl.Bindings {
+			if binding.Kind == BindingOutput {
+				if binding.TargetNode != nil {
+					edges = append(edges, callEdge{
+						caller:       binding.Source,
+						callerSource: HierarchySourceTree,
+						callee:       cp.propertyPath(decl, binding.TargetNode),
+						site:         binding.TargetNode.Range,
+					})
+				}
+				continue
+			}
+
+			caller := binding.TargetNode
+			if caller == nil {
+				// List items and dictionary values belong to the enclosing property
+				caller = cp.ownerNode(decl, binding.Node)
+			}
+			if caller == nil {
+				continue
+			}
+			edges = append(edges, callEdge{
+				caller:       cp.propertyPath(decl, caller),
+				callerSource: HierarchySourceTree,
+				callee:       binding.Source,
+				site:         binding.SourceNode.Range,
+			})
+		}
+
+		for _, instance := range decl.Instances {
+			if instance.Property == nil {
+				continue
+			}
+			for _, override := range instance.Overrides {
+				edges = append(edges, callEdge{
+					caller:       instance.Property.Name,
+					callerSource: HierarchySourceTree,
+					callee:       instance.Property.Name + "." + override.Name,
+					site:         override.Node.Range,
+				})
+			}
+		}
+	}
+
+	if class := cp.projectScanner.GetTsClass(component); class != nil {
+		names := make([]string, 0, len(class.Methods))
+		for name := range class.Methods {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			method := class.Methods[name]
+			for i, call := range method.Calls {
+				edges = append(edges, callEdge{
+					caller:       name,
+					callerSource: HierarchySourceTs,
+					callee:       call,
+					site:         method.CallRanges[i],
+				})
+			}
+		}
+	}
+
+	return edges
+}
+
+// propertyPath names a property node: "value" for own properties and
+// "Selector.value" for overrides of the instance created by Selector
+func (cp *CallHierarchyProvider) propertyPath(decl *ComponentDecl, node *TreeNode) string {
+	name := propertyBaseName(node.Name)
+	if parent := node.Parent; parent != nil && parent != decl.Node && parent != decl.BaseNode && strings.HasPrefix(parent.Name, "$") {
+		for _, instance := range decl.Instances {
+			if instance.Node == parent && instance.Property != nil {
+				return instance.Property.Name + "." + name
+			}
+		}
+	}
+	return name
+}
+
+// ownerNode returns the nearest property node enclosing a node, skipping
+// literals and dictionary keys
+func (cp *CallHierarchyProvider) ownerNode(decl *ComponentDecl, node *TreeNode) *TreeNode {
+	for owner := node.Parent; owner != nil && owner != decl.Node && owner != decl.BaseNode; owner = owner.Parent {
+		if owner.IsData() || !isPropertyName(owner.Name) {
+			continue
+		}
+		if owner.Parent != nil && strings.HasPrefix(owner.Parent.Name, "*") {
+			continue
+		}
+		return owner
+	}
+	return nil
+}
+
+// item describes a property of a component. source selects the view.tree
+// node or the TypeScript method; "" takes the view.tree node when there is one.
+func (cp *CallHierarchyProvider) item(inferrer *TypeInferrer, component, path, source string) *CallHierarchyItem {
+	if source != HierarchySourceTs {
+		if decl := inferrer.componentDecl(component); decl != nil {
+			if node := cp.findPropertyNode(decl, path); node != nil {
+				return &CallHierarchyItem{
+					Name:           path,
+					Kind:           SymbolKindProperty,
+					Detail:         component,
+					URI:            pathToURI(decl.File),
+					Range:          node.Range,
+					SelectionRange: node.Range,
+					Data:           &HierarchyData{Component: component, Source: HierarchySourceTree, Property: path},
+				}
+			}
+		}
+		if source == HierarchySourceTree {
+			return nil
+		}
+	}
+
+	class := cp.projectScanner.GetTsClass(component)
+	if class == nil || class.Methods[path] == nil {
+		return nil
+	}
+	method := class.Methods[path]
+	return &CallHierarchyItem{
+		Name:           path,
+		Kind:           SymbolKindMethod,
+		Detail:         component + " (TypeScript)",
+		URI:            pathToURI(class.File),
+		Range:          method.Range,
+		SelectionRange: method.Range,
+		Data:           &HierarchyData{Component: component, Source: HierarchySourceTs, Property: path},
+	}
+}
+
+// findPropertyNode returns the node declaring a property path in a component
+func (cp *CallHierarchyProvider) findPropertyNode(decl *ComponentDecl, path string) *TreeNode {
+	owner, name, isOverride := strings.Cut(path, ".")
+	if !isOverride {
+		if property := decl.Property(path); property != nil {
+			return property.Node
+		}
+		return nil
+	}
+
+	for _, instance := range decl.Instances {
+		if instance.Property == nil || instance.Property.Name != owner {
+			continue
+		}
+		for _, override := range instance.Overrides {
+			if override.Name == name {
+				return override.Node
+			}
+		}
+	}
+	return nil
+}
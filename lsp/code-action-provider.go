@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strings"
+)
+
+type CodeActionProvider struct {
+	projectScanner *ProjectScanner
+}
+
+func NewCodeActionProvider(projectScanner *ProjectScanner) *CodeActionProvider {
+	return &CodeActionProvider{
+		projectScanner: projectScanner,
+	}
+}
+
+// ProvideCodeActions offers quick fixes for the localized strings in the range
+func (cp *CodeActionProvider) ProvideCodeActions(document *TextDocument, params CodeActionParams) []CodeAction {
+	actions := []CodeAction{}
+	if !strings.HasSuffix(document.URI, ".view.tree") || !cp.wantsKind(params.Context.Only, CodeActionKindQuickFix) {
+		return actions
+	}
+
+	documentFile := uriToPath(document.URI)
+	files := LocaleFilesFor(documentFile)
+	if len(files) == 0 {
+		return actions
+	}
+
+	decls := BuildComponentDecls(ParseTree(document.Text), documentFile)
+	for _, localized := range LocaleStrings(decls) {
+		if !rangesOverlap(localized.Range(), params.Range) {
+			continue
+		}
+		if action := cp.addTranslationStubs(localized, files, params.Context.Diagnostics); action != nil {
+			actions = append(actions, *action)
+		}
+	}
+
+	return actions
+}
+
+// addTranslationStubs adds the source text under the missing key to every
+// locale file lacking it
+func (cp *CodeActionProvider) addTranslationStubs(localized *LocaleString, files []*LocaleFile, diagnostics []Diagnostic) *CodeAction {
+	missing := localized.MissingIn(files)
+	changes := make(map[string][]TextEdit)
+	for _, file := range missing {
+		if edit, ok := file.StubEdit(localized.Key, localized.Text); ok {
+			changes[pathToURI(file.File)] = []TextEdit{edit}
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	action := &CodeAction{
+		Title:       "Add '" + localized.Key + "' to locale " + localeLanguages(missing),
+		Kind:        CodeActionKindQuickFix,
+		IsPreferred: true,
+		Edit:        &WorkspaceEdit{Changes: changes},
+	}
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Code == RuleMissingTranslation && rangesOverlap(diagnostic.Range, localized.Range()) {
+			action.Diagnostics = append(action.Diagnostics, diagnostic)
+		}
+	}
+	return action
+}
+
+// wantsKind reports whether the client asked for the kind, directly or via a
+// parent kind such as "quickfix" for "quickfix.locale"
+func (cp *CodeActionProvider) wantsKind(only []string, kind string) bool {
+	if len(only) == 0 {
+		return true
+	}
+	for _, requested := range only {
+		if kind == requested || strings.HasPrefix(kind, requested+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// rangesOverlap reports whether two ranges share a position, touching included
+func rangesOverlap(a, b Range) bool {
+	return !positionBefore(a.End, b.Start) && !positionBefore(b.End, a.Start)
+}
+
+func positionBefore(a, b Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	CodeLensReferences = "references" // "5 references"
+	CodeLensUsages     = "usages"     // "used by 3 components"
+	CodeLensOverride   = "override"   // "overridden in app.view.ts"
+)
+
+// CodeLensData identifies what a code lens counts until it is resolved
+type CodeLensData struct {
+	URI       string `json:"uri"`
+	Component string `json:"component"`
+	Property  string `json:"property,omitempty"`
+	Kind      string `json:"kind"`
+}
+
+// CodeLensProvider puts reference counts and TypeScript override markers on
+// root components and their first-level properties. Lenses are returned
+// without commands and counted from the project index on codeLens/resolve.
+type CodeLensProvider struct {
+	projectScanner *ProjectScanner
+	typeInferrer   *TypeInferrer
+}
+
+func NewCodeLensProvider(projectScanner *ProjectScanner) *CodeLensProvider {
+	return &CodeLensProvider{
+		projectScanner: projectScanner,
+		typeInferrer:   NewTypeInferrer(projectScanner),
+	}
+}
+
+// ProvideCodeLenses returns the unresolved lenses of a document
+func (cp *CodeLensProvider) ProvideCodeLenses(document *TextDocument) []CodeLens {
+	lenses := []CodeLens{}
+	decls := BuildComponentDecls(ParseTree(document.Text), uriToPath(document.URI))
+
+	add := func(lensRange Range, component, property, kind string) {
+		lenses = append(lenses, CodeLens{
+			Range: lensRange,
+			Data:  &CodeLensData{URI: document.URI, Component: component, Property: property, Kind: kind},
+		})
+	}
+
+	for _, decl := range decls {
+		add(decl.Node.Range, decl.Name, "", CodeLensReferences)
+		add(decl.Node.Range, decl.Name, "", CodeLensUsages)
+		tsClass := cp.projectScanner.GetTsClass(decl.Name)
+		if tsClass != nil {
+			add(decl.Node.Range, decl.Name, "", CodeLensOverride)
+		}
+
+		for _, property := range decl.Properties {
+			if property.Via != "" {
+				continue
+			}
+			add(property.Node.Range, decl.Name, property.Name, CodeLensReferences)
+			if tsClass != nil && tsClass.Methods[property.Name] != nil {
+				add(property.Node.Range, decl.Name, property.Name, CodeLensOverride)
+			}
+		}
+	}
+	return lenses
+}
+
+// ResolveCodeLens fills in the title of a lens
+func (cp *CodeLensProvider) ResolveCodeLens(lens CodeLens, data CodeLensData) CodeLens {
+	title := ""
+	switch data.Kind {
+	case CodeLensReferences:
+		var count int
+		if data.Property == "" {
+			count, _ = cp.componentReferences(data.Component)
+		} else {
+			count = cp.propertyReferences(data.Component, data.Property)
+		}
+		title = plural(count, "reference", "references")
+	case CodeLensUsages:
+		_, users := cp.componentReferences(data.Component)
+		title = "used by " + plural(users, "component", "components")
+	case CodeLensOverride:
+		if tsClass := cp.projectScanner.GetTsClass(data.Component); tsClass != nil {
+			title = "overridden in " + filepath.Base(tsClass.File)
+		}
+	}
+
+	lens.Command = &Command{Title: title, Command: ""}
+	return lens
+}
+
+// componentReferences counts the places extending, instantiating or typing
+// a list with the component, and TypeScript files mentioning it, along with
+// the number of distinct view.tree components doing so
+func (cp *CodeLensProvider) componentReferences(component string) (int, int) {
+	count := 0
+	users := make(map[string]bool)
+	cp.eachDecl(func(decl *ComponentDecl) {
+		before := count
+		if decl.Base == component {
+			count++
+		}
+		for _, instance := range decl.Instances {
+			if instance.Component == component {
+				count++
+			}
+		}
+		decl.Node.Walk(func(node *TreeNode) bool {
+			if !node.IsData() && (node.Name == "/"+component || node.Name == "*"+component) {
+				count++
+			}
+			return true
+		})
+		if count > before {
+			users[decl.Name] = true
+		}
+	})
+
+	for _, scanner := range cp.projectScanner.visibleScanners() {
+		data := scanner.project()
+		data.mutex.RLock()
+		for filePath, components := range data.FileComponents {
+			if !strings.HasSuffix(filePath, ".ts") || !components[component] {
+				continue
+			}
+			// The behaviour class of a component does not reference it
+			if class := data.TsClasses[component]; class != nil && class.File == filePath {
+				continue
+			}
+			count++
+		}
+		data.mutex.RUnlock()
+	}
+	return count, len(users)
+}
+
+// propertyReferences counts the bindings to a property in the component and
+// its subclasses, overrides by subclasses and instances, and this.x() calls
+// in TypeScript classes of the hierarchy
+func (cp *CodeLensProvider) propertyReferences(component, property string) int {
+	chains := make(map[string]bool)
+	inherits := func(class string) bool {
+		if inherited, ok := chains[class]; ok {
+			return inherited
+		}
+		inherited := false
+		for _, ancestor := range cp.typeInferrer.ClassChain(class) {
+			if ancestor == component {
+				inherited = true
+				break
+			}
+		}
+		chains[class] = inherited
+		return inherited
+	}
+
+	count := 0
+	cp.eachDecl(func(decl *ComponentDecl) {
+		if inherits(decl.Name) {
+			for _, binding := range decl.Bindings {
+				if binding.Source == property {
+					count++
+				}
+			}
+			if own := decl.Property(property); decl.Name != component && own != nil && own.Via == "" {
+				count++
+			}
+		}
+		for _, instance := range decl.Instances {
+			if !inherits(instance.Component) {
+				continue
+			}
+			for _, override := range instance.Overrides {
+				if override.Name == property {
+					count++
+				}
+			}
+		}
+	})
+
+	var classes []*TsClass
+	for _, scanner := range cp.projectScanner.visibleScanners() {
+		data := scanner.project()
+		data.mutex.RLock()
+		for _, class := range data.TsClasses {
+			classes = append(classes, class)
+		}
+		data.mutex.RUnlock()
+	}
+	for _, class := range classes {
+		if !inherits(class.Name) {
+			continue
+		}
+		for _, method := range class.Methods {
+			for _, call := range method.Calls {
+				if call == property {
+					count++
+				}
+			}
+		}
+	}
+	return count
+}
+
+// eachDecl visits the view.tree declarations of the project and libraries
+func (cp *CodeLensProvider) eachDecl(visit func(decl *ComponentDecl)) {
+	var decls []*ComponentDecl
+	collect := func(data *ProjectData) {
+		data.mutex.RLock()
+		defer data.mutex.RUnlock()
+		for _, decl := range data.Declarations {
+			decls = append(decls, decl)
+		}
+	}
+	for _, scanner := range cp.projectScanner.visibleScanners() {
+		collect(scanner.project())
+		collect(scanner.library())
+	}
+
+	// Visit outside the locks, the type inferrer takes them again
+	for _, decl := range decls {
+		visit(decl)
+	}
+}
+
+// plural formats a count with the matching noun
+func plural(count int, one, many string) string {
+	if count == 1 {
+		return fmt.Sprintf("1 %s", one)
+	}
+	return fmt.Sprintf("%d %s", count, many)
+}
@@ -0,0 +1,176 @@
+package main
+
+// projectCounts are the component usages and property overrides counted over
+// the view.tree declarations of the project and its libraries. Counts are kept
+// per file as well, so a single file update only recounts that file. Usages
+// and overrides handed out are never modified, updates work on a copy.
+type projectCounts struct {
+	usages    map[string]int            // component -> extends and instances
+	overrides map[string]map[string]int // component -> property -> overrides
+	files     map[countedFile]*projectCounts
+
+	// Index revision and project index of every visible root when counted
+	revisions []uint64
+	data      []*ProjectData
+}
+
+// countedFile is a file of one index
+type countedFile struct {
+	data *ProjectData
+	file string
+}
+
+func newProjectCounts() *projectCounts {
+	return &projectCounts{
+		usages:    make(map[string]int),
+		overrides: make(map[string]map[string]int),
+	}
+}
+
+// projectCounts returns the counts of the current index, recounting only the
+// files updated since the last call when possible
+func (cp *CompletionProvider) projectCounts() *projectCounts {
+	scanners := cp.projectScanner.visibleScanners()
+	revisions := make([]uint64, len(scanners))
+	files := make([]string, len(scanners))
+	data := make([]*ProjectData, len(scanners))
+	for i, scanner := range scanners {
+		revisions[i], files[i] = scanner.indexRevision()
+		data[i] = scanner.project()
+	}
+
+	cp.countsMutex.Lock()
+	defer cp.countsMutex.Unlock()
+
+	if counts := cp.counts; counts != nil {
+		if updated, ok := counts.updatedFiles(revisions, files, data); ok {
+			if len(updated) > 0 {
+				counts = counts.recount(updated)
+				cp.counts = counts
+			}
+			counts.revisions = revisions
+			return counts
+		}
+	}
+
+	counts := newProjectCounts()
+	counts.files = make(map[countedFile]*projectCounts)
+	for _, scanner := range scanners {
+		counts.addIndex(scanner.project())
+		counts.addIndex(scanner.library())
+	}
+	counts.revisions = revisions
+	counts.data = data
+	cp.counts = counts
+	return counts
+}
+
+// updatedFiles returns the files updated since the counts were taken, or
+// false when everything has to be counted again
+func (pc *projectCounts) updatedFiles(revisions []uint64, files []string, data []*ProjectData) ([]countedFile, bool) {
+	if len(pc.revisions) != len(revisions) {
+		return nil, false
+	}
+
+	var updated []countedFile
+	for i, revision := range revisions {
+		switch {
+		case data[i] != pc.data[i]:
+			return nil, false
+		case revision == pc.revisions[i]:
+		case revision == pc.revisions[i]+1 && files[i] != "":
+			updated = append(updated, countedFile{data: data[i], file: files[i]})
+		default:
+			// Several updates or a full scan since the counts were taken
+			return nil, false
+		}
+	}
+	return updated, true
+}
+
+// addIndex counts every declaration of an index
+func (pc *projectCounts) addIndex(data *ProjectData) {
+	data.mutex.RLock()
+	defer data.mutex.RUnlock()
+
+	files := make(map[countedFile]*projectCounts)
+	for _, decl := range data.Declarations {
+		key := countedFile{data: data, file: decl.File}
+		if files[key] == nil {
+			files[key] = newProjectCounts()
+		}
+		files[key].addDecl(decl)
+	}
+	for key, file := range files {
+		pc.files[key] = file
+		pc.merge(file, 1)
+	}
+}
+
+// recount returns a copy of the counts with the files counted again
+func (pc *projectCounts) recount(files []countedFile) *projectCounts {
+	next := newProjectCounts()
+	next.merge(pc, 1)
+	next.data = pc.data
+	next.files = make(map[countedFile]*projectCounts, len(pc.files))
+	for key, file := range pc.files {
+		next.files[key] = file
+	}
+
+	for _, key := range files {
+		if previous := next.files[key]; previous != nil {
+			next.merge(previous, -1)
+		}
+
+		file := newProjectCounts()
+		key.data.mutex.RLock()
+		for _, decl := range key.data.Declarations {
+			if decl.File == key.file {
+				file.addDecl(decl)
+			}
+		}
+		key.data.mutex.RUnlock()
+
+		next.files[key] = file
+		next.merge(file, 1)
+	}
+	return next
+}
+
+// addDecl counts what a declaration extends and instantiates, and the
+// properties its instances override
+func (pc *projectCounts) addDecl(decl *ComponentDecl) {
+	if decl.Base != "" {
+		pc.usages[decl.Base]++
+	}
+	for _, instance := range decl.Instances {
+		pc.usages[instance.Component]++
+		if pc.overrides[instance.Component] == nil {
+			pc.overrides[instance.Component] = make(map[string]int)
+		}
+		for _, override := range instance.Overrides {
+			pc.overrides[instance.Component][override.Name]++
+		}
+	}
+}
+
+// merge adds the counts of other, or subtracts them for a negative sign
+func (pc *projectCounts) merge(other *projectCounts, sign int) {
+	for component, count := range other.usages {
+		if pc.usages[component] += sign * count; pc.usages[component] <= 0 {
+			delete(pc.usages, component)
+		}
+	}
+	for component, properties := range other.overrides {
+		merged := make(map[string]int, len(pc.overrides[component]))
+		for name, count := range pc.overrides[component] {
+			merged[name] = count
+		}
+		for name, count := range properties {
+			if merged[name] += sign * count; merged[name] <= 0 {
+				delete(merged, name)
+			}
+		}
+		pc.overrides[component] = merged
+	}
+}
@@ -3,27 +3,56 @@ package main
 import (
 	"fmt"
 	"log"
+	"sort"
 	"strings"
+	"sync"
 )
 
 type InternalCompletionContext struct {
-	Type             string // "component_name", "component_extends", "property_name", "property_binding", "value"
+	Type             string // "component_name", "component_extends", "property_name", "property_binding", "bindable_property", "list_item", "dict_key", "value", "none"
 	IndentLevel      int
-	CurrentComponent string
+	CurrentComponent string           // class whose properties are completed
+	Override         bool             // properties are overrides of a child component
+	ElementType      *ValueType       // item type inside "/type" lists
+	Decls            []*ComponentDecl // declarations of the document being edited
+}
+
+// CompletionClient is what the client supports in completion items
+type CompletionClient struct {
+	SnippetSupport           bool
+	InsertReplaceSupport     bool
+	CommitCharactersSupport  bool
+	AdjustIndentationSupport bool
 }
 
 type CompletionProvider struct {
 	projectScanner *ProjectScanner
 	parser         *ViewTreeParser
+	typeInferrer   *TypeInferrer
+	client         CompletionClient
+	
+	// Labels of accepted items, most recent first
+	recent      []string
+	recentMutex sync.Mutex
+	
+	// Component usages and property overrides of the current index
+	counts      *projectCounts
+	countsMutex sync.Mutex
 }
 
 func NewCompletionProvider(projectScanner *ProjectScanner) *CompletionProvider {
 	return &CompletionProvider{
 		projectScanner: projectScanner,
 		parser:         NewViewTreeParser(),
+		typeInferrer:   NewTypeInferrer(projectScanner),
 	}
 }
 
+// SetClient stores the completion capabilities announced by the client
+func (cp *CompletionProvider) SetClient(client CompletionClient) {
+	cp.client = client
+}
+
 func (cp *CompletionProvider) ProvideCompletionItems(document *TextDocument, position Position) ([]CompletionItem, error) {
 	log.Printf("[completion] Request at %d:%d", position.Line, position.Character)
 
@@ -45,6 +74,7 @@ func (cp *CompletionProvider) ProvideCompletionItems(document *TextDocument, pos
 	var items []CompletionItem
 	completionContext := cp.getCompletionContext(content, position, beforeCursor)
 	log.Printf("[completion] Context: %s, indent: %d", completionContext.Type, completionContext.IndentLevel)
+	inferrer := cp.typeInferrer.ForDocument(completionContext.Decls)
 
 	switch completionContext.Type {
 	case "component_name":
@@ -55,107 +85,246 @@ func (cp *CompletionProvider) ProvideCompletionItems(document *TextDocument, pos
 		cp.addComponentCompletions(&items)
 	case "property_name":
 		log.Printf("[completion] Adding property completions for component: %s", completionContext.CurrentComponent)
-		cp.addPropertyCompletions(&items, completionContext.CurrentComponent)
+		cp.addPropertyCompletions(&items, inferrer, completionContext.CurrentComponent, completionContext.Override)
+		cp.addListMarkerCompletion(&items)
+		if !completionContext.Override {
+			if owner := ComponentAt(completionContext.Decls, position.Line); owner != nil {
+				cp.addChildScaffolds(&items, inferrer, nil, owner, content, cp.typedWord(content, position))
+			}
+		}
+	case "bindable_property":
+		log.Printf("[completion] Adding bindable properties of %s", completionContext.CurrentComponent)
+		cp.addPropertyCompletions(&items, inferrer, completionContext.CurrentComponent, false)
 	case "property_binding":
 		log.Println("[completion] Adding binding completions")
 		cp.addBindingCompletions(&items)
+		cp.addChildComponentCompletion(&items)
+		cp.addValueCompletions(&items)
+	case "list_item":
+		log.Printf("[completion] Adding list items of type %s", completionContext.ElementType)
+		cp.addListItemCompletions(&items, inferrer, completionContext.ElementType)
+		if kind := completionContext.ElementType.Kind; kind == TypeComponent || kind == TypeUnknown {
+			cp.addChildScaffolds(&items, inferrer, completionContext.ElementType, nil, content, cp.typedWord(content, position))
+		}
+	case "dict_key":
+		cp.addOverrideCompletion(&items)
 	case "value":
 		log.Println("[completion] Adding value completions")
 		cp.addValueCompletions(&items)
 	}
 
+	cp.applyClientSupport(items, content, position)
+	for i := range items {
+		// Documentation is filled in by completionItem/resolve
+		if data, ok := items[i].Data.(*CompletionItemData); ok {
+			data.URI = document.URI
+		}
+	}
+
 	log.Printf("[completion] Returning %d items", len(items))
 	return items, nil
 }
 
-func (cp *CompletionProvider) getCompletionContext(content string, position Position, beforeCursor string) InternalCompletionContext {
-	trimmed := strings.TrimSpace(beforeCursor)
-	// Match reference logic: beforeCursor.length - beforeCursor.trimStart().length
-	trimStart := strings.TrimLeftFunc(beforeCursor, func(r rune) bool {
-		return r == ' ' || r == '\t' || r == '\n' || r == '\r'
-	})
-	indentLevel := len(beforeCursor) - len(trimStart)
-
-	// If starts with $ anywhere - it's a component
-	if strings.HasPrefix(trimmed, "$") {
-		return InternalCompletionContext{Type: "component_name", IndentLevel: indentLevel, CurrentComponent: ""}
+// applyClientSupport turns the insert texts into edits of the word being
+// typed and sets commit characters and indentation modes the client supports
+func (cp *CompletionProvider) applyClientSupport(items []CompletionItem, content string, position Position) {
+	lines := strings.Split(content, "\n")
+	if position.Line >= len(lines) {
+		return
 	}
-
-	// If at root level and no space - it's a component
-	if indentLevel == 0 && !strings.Contains(trimmed, " ") {
-		return InternalCompletionContext{Type: "component_name", IndentLevel: indentLevel, CurrentComponent: ""}
+	line := lines[position.Line]
+	cursor := position.Character
+	if cursor > len(line) {
+		cursor = len(line)
 	}
 
-	// If at root level and has space - it's inheritance
-	if indentLevel == 0 && strings.Contains(trimmed, " ") {
-		return InternalCompletionContext{Type: "component_extends", IndentLevel: indentLevel, CurrentComponent: ""}
+	wordStart, wordEnd := cursor, cursor
+	if word := cp.parser.GetWordRangeAtPosition(content, position); word != nil {
+		wordStart, wordEnd = word.Start.Character, word.End.Character
 	}
-
-	// If has binding operators
-	if strings.Contains(trimmed, "<=") {
-		return InternalCompletionContext{Type: "property_binding", IndentLevel: indentLevel, CurrentComponent: ""}
+	// Operators and literals are not words: an item continuing the whole
+	// token typed so far replaces it
+	tokenStart := cursor
+	for tokenStart > 0 && line[tokenStart-1] != ' ' && line[tokenStart-1] != '\t' {
+		tokenStart--
 	}
 
-	// If indented - it's a property
-	if indentLevel > 0 {
-		currentComponent := cp.getCurrentComponent(content, position)
-		return InternalCompletionContext{Type: "property_name", IndentLevel: indentLevel, CurrentComponent: currentComponent}
+	for i := range items {
+		item := &items[i]
+		start := wordStart
+		if tokenStart < start && strings.HasPrefix(item.Label, line[tokenStart:cursor]) {
+			start = tokenStart
+		}
+		insert := Range{
+			Start: Position{Line: position.Line, Character: start},
+			End:   Position{Line: position.Line, Character: cursor},
+		}
+		replace := Range{Start: insert.Start, End: Position{Line: position.Line, Character: wordEnd}}
+
+		if cp.client.InsertReplaceSupport {
+			item.TextEdit = &InsertReplaceEdit{NewText: item.InsertText, Insert: insert, Replace: replace}
+		} else {
+			item.TextEdit = &TextEdit{Range: replace, NewText: item.InsertText}
+		}
+
+		multiLine := strings.Contains(item.InsertText, "\n")
+		if multiLine && cp.client.AdjustIndentationSupport {
+			item.InsertTextMode = InsertTextModeAdjustIndentation
+		} else if multiLine {
+			// Indent the following lines like the current one ourselves
+			indent := line[:len(line)-len(strings.TrimLeft(line, "\t"))]
+			newText := strings.ReplaceAll(item.InsertText, "\n", "\n"+indent)
+			switch edit := item.TextEdit.(type) {
+			case *InsertReplaceEdit:
+				edit.NewText = newText
+			case *TextEdit:
+				edit.NewText = newText
+			}
+		}
+		if cp.client.CommitCharactersSupport && !multiLine && item.InsertTextFormat != InsertTextFormatSnippet {
+			item.CommitCharacters = []string{" "}
+		}
 	}
+}
 
-	return InternalCompletionContext{Type: "value", IndentLevel: indentLevel, CurrentComponent: ""}
+// snippets reports whether items may carry snippets with tab stops
+func (cp *CompletionProvider) snippets() bool {
+	return cp.client.SnippetSupport && cp.projectScanner.Settings().Completion.SnippetStyle != SnippetStylePlain
 }
 
-func (cp *CompletionProvider) getCurrentComponent(content string, position Position) string {
-	return cp.parser.GetCurrentComponent(content, position)
+// snippetEscape escapes the characters with a meaning in snippets
+func snippetEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `$`, `\$`, `}`, `\}`).Replace(text)
 }
 
-func (cp *CompletionProvider) addComponentCompletions(items *[]CompletionItem) {
-	projectData := cp.projectScanner.GetProjectData()
+// getCompletionContext decides what fits at the position from the node the
+// typed word would attach to in the syntax tree
+func (cp *CompletionProvider) getCompletionContext(content string, position Position, beforeCursor string) InternalCompletionContext {
+	// Match reference logic: beforeCursor.length - beforeCursor.trimStart().length
+	trimStart := strings.TrimLeftFunc(beforeCursor, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	indentLevel := len(beforeCursor) - len(trimStart)
+	completionContext := InternalCompletionContext{Type: "none", IndentLevel: indentLevel}
+	
+	tree, parent := InsertionPoint(content, position)
+	completionContext.Decls = BuildComponentDecls(tree, "")
+	if parent == nil {
+		return completionContext
+	}
+	decl := ComponentAt(completionContext.Decls, position.Line)
+	
+	switch {
+	case parent.Parent == nil:
+		completionContext.Type = "component_name"
+	case parent.Parent.Parent == nil && parent.Line == position.Line:
+		completionContext.Type = "component_extends"
+	case decl != nil && (parent == decl.Node || parent == decl.BaseNode):
+		completionContext.Type = "property_name"
+		completionContext.CurrentComponent = decl.Name
+	case strings.HasPrefix(parent.Name, "$"):
+		// Overrides of a child component
+		completionContext.Type = "property_name"
+		completionContext.CurrentComponent = parent.Name
+		completionContext.Override = true
+	case parent.Name == BindingOneWay || parent.Name == BindingTwoWay || parent.Name == BindingOutput:
+		if decl != nil {
+			completionContext.Type = "bindable_property"
+			completionContext.CurrentComponent = decl.Name
+		}
+	case strings.HasPrefix(parent.Name, "/"):
+		completionContext.Type = "list_item"
+		completionContext.ElementType = ParseElementType(strings.TrimPrefix(parent.Name, "/"))
+	case strings.HasPrefix(parent.Name, "*"):
+		completionContext.Type = "dict_key"
+	case parent.Parent != nil && strings.HasPrefix(parent.Parent.Name, "*"):
+		completionContext.Type = "value"
+	case isPropertyName(parent.Name):
+		completionContext.Type = "property_binding"
+	}
+	
+	return completionContext
+}
 
-	projectData.mutex.RLock()
-	componentCount := len(projectData.Components)
-	projectData.mutex.RUnlock()
+func (cp *CompletionProvider) addComponentCompletions(items *[]CompletionItem) {
+	// Components of this root and of peer roots sharing a namespace
+	components := cp.projectScanner.GetComponents()
 
-	log.Printf("[completion] Project has %d components", componentCount)
+	log.Printf("[completion] Project has %d components", len(components))
 
-	// Add components from project
-	projectData.mutex.RLock()
-	for component := range projectData.Components {
-		item := CompletionItem{
-			Label:         component,
-			Kind:          CompletionItemKindClass,
-			InsertText:    component,
-			SortText:      "1" + component,
-			Detail:        "Component",
-			Documentation: fmt.Sprintf("Component: %s", component),
-		}
-		*items = append(*items, item)
+	for _, component := range components {
+		*items = append(*items, cp.componentItem(component))
 	}
-	projectData.mutex.RUnlock()
 
-	log.Printf("[completion] Added %d component completions", componentCount)
+	log.Printf("[completion] Added %d component completions", len(components))
 }
 
-func (cp *CompletionProvider) addPropertyCompletions(items *[]CompletionItem, currentComponent string) {
-	projectData := cp.projectScanner.GetProjectData()
+// componentItem is the completion item of a component name
+func (cp *CompletionProvider) componentItem(component string) CompletionItem {
+	item := CompletionItem{
+		Label:         component,
+		Kind:          CompletionItemKindClass,
+		InsertText:    component,
+		SortText:      "1" + component,
+		Detail:        "Component",
+		Data:          &CompletionItemData{Component: component},
+	}
+	// Library components rank below workspace ones
+	if cp.projectScanner.IsLibraryComponent(component) {
+		item.SortText = "2" + component
+		item.Detail = "Library component"
+	}
+	return item
+}
 
+// addPropertyCompletions offers the properties of a class and its bases,
+// own ones first. Overrides of a mutable property bind it two-way and list
+// properties open a nested item.
+func (cp *CompletionProvider) addPropertyCompletions(items *[]CompletionItem, inferrer *TypeInferrer, currentComponent string, override bool) {
 	// Add properties for current component
 	if currentComponent != "" {
-		projectData.mutex.RLock()
-		if properties, exists := projectData.ComponentProperties[currentComponent]; exists {
-			for property := range properties {
+		seen := map[string]bool{insertionMarker: true}
+		for depth, class := range inferrer.ClassChain(currentComponent) {
+			var properties []string
+			declared := make(map[string]*PropertyDecl)
+			if decl := inferrer.componentDecl(class); decl != nil {
+				for _, property := range decl.Properties {
+					properties = append(properties, property.Name)
+					declared[property.Name] = property
+				}
+			}
+			if tsClass := cp.projectScanner.GetTsClass(class); tsClass != nil {
+				for method := range tsClass.Methods {
+					properties = append(properties, method)
+				}
+			}
+			sort.Strings(properties)
+			
+			sortPrefix := "1"
+			if depth > 0 {
+				sortPrefix = "2"
+			}
+			for _, property := range properties {
+				if seen[property] {
+					continue
+				}
+				seen[property] = true
 				item := CompletionItem{
 					Label:         property,
 					Kind:          CompletionItemKindProperty,
 					InsertText:    property,
-					SortText:      "1" + property,
-					Detail:        fmt.Sprintf("Property of %s", currentComponent),
-					Documentation: fmt.Sprintf("Property from component %s", currentComponent),
+					SortText:      sortPrefix + property,
+					Detail:        fmt.Sprintf("Property of %s", class),
+					Data:          &CompletionItemData{Component: class, Property: property},
+				}
+				propertyType := inferrer.PropertyType(currentComponent, property)
+				if propertyType.Kind != TypeUnknown {
+					item.Detail = fmt.Sprintf("%s: %s", item.Detail, propertyType)
 				}
+				cp.setPropertyInsertText(&item, declared[property], propertyType, override)
 				*items = append(*items, item)
 			}
 		}
-		projectData.mutex.RUnlock()
 	}
 
 	// Add common properties if component not found
@@ -168,13 +337,53 @@ func (cp *CompletionProvider) addPropertyCompletions(items *[]CompletionItem, cu
 				InsertText:    property,
 				SortText:      "2" + property,
 				Detail:        "Property",
-				Documentation: "Property from project",
 			}
 			*items = append(*items, item)
 		}
 	}
+}
+
+// setPropertyInsertText fills in the value scaffold of a property, e.g.
+// "value? <=> ${1:value}? ${2:null}" for an override of a mutable property
+func (cp *CompletionProvider) setPropertyInsertText(item *CompletionItem, property *PropertyDecl, propertyType *ValueType, override bool) {
+	signature := item.Label
+	if override && property != nil && property.Mutable {
+		signature += "?"
+	}
+	item.InsertText = signature
+	if !cp.snippets() {
+		return
+	}
+
+	name := snippetEscape(item.Label)
+	switch {
+	case propertyType.Kind == TypeList:
+		item.InsertText = snippetEscape(signature) + " /\n\t$0"
+	case override && property != nil && property.Mutable:
+		item.InsertText = fmt.Sprintf("%s? <=> ${1:%s}? ${2:null}", name, name)
+	default:
+		return
+	}
+	item.InsertTextFormat = InsertTextFormatSnippet
+}
+
+// addChildComponentCompletion offers a "<= Name $component" scaffold
+func (cp *CompletionProvider) addChildComponentCompletion(items *[]CompletionItem) {
+	if !cp.snippets() {
+		return
+	}
+	*items = append(*items, CompletionItem{
+		Label:            "<= Name $component",
+		Kind:             CompletionItemKindSnippet,
+		InsertText:       "<= ${1:Name} \\$${2:component}",
+		InsertTextFormat: InsertTextFormatSnippet,
+		SortText:         "0<= Name",
+		Detail:           "Child component",
+		Documentation:    "Creates a child component in a property",
+	})
+}
 
-	// Add list marker
+func (cp *CompletionProvider) addListMarkerCompletion(items *[]CompletionItem) {
 	listItem := CompletionItem{
 		Label:         "/",
 		Kind:          CompletionItemKindOperator,
@@ -186,6 +395,67 @@ func (cp *CompletionProvider) addPropertyCompletions(items *[]CompletionItem, cu
 	*items = append(*items, listItem)
 }
 
+func (cp *CompletionProvider) addOverrideCompletion(items *[]CompletionItem) {
+	*items = append(*items, CompletionItem{
+		Label:         "^",
+		Kind:          CompletionItemKindOperator,
+		InsertText:    "^",
+		SortText:      "0^",
+		Detail:        "Override",
+		Documentation: "Keeps the items of the base class",
+	})
+}
+
+// addListItemCompletions offers items matching the element type of a
+// "/type" list: a "<=" binding, literals of the type or components
+// assignable to it
+func (cp *CompletionProvider) addListItemCompletions(items *[]CompletionItem, inferrer *TypeInferrer, elementType *ValueType) {
+	*items = append(*items, CompletionItem{
+		Label:         "<=",
+		Kind:          CompletionItemKindOperator,
+		InsertText:    "<=",
+		SortText:      "0<=",
+		Detail:        "One-way binding",
+		Documentation: "Takes the item from a property",
+	})
+	cp.addOverrideCompletion(items)
+	
+	switch elementType.Kind {
+	case TypeString:
+		cp.addLiteralCompletions(items, "\\", "@\\")
+	case TypeBoolean:
+		cp.addLiteralCompletions(items, "true", "false")
+	case TypeNull:
+		cp.addLiteralCompletions(items, "null")
+	case TypeComponent:
+		cp.addChildComponentCompletion(items)
+		var candidates []CompletionItem
+		cp.addComponentCompletions(&candidates)
+		for _, candidate := range candidates {
+			if inferrer.IsAssignable(&ValueType{Kind: TypeComponent, Component: candidate.Label}, elementType) {
+				*items = append(*items, candidate)
+			}
+		}
+	case TypeNumber:
+	default:
+		cp.addChildComponentCompletion(items)
+		cp.addValueCompletions(items)
+	}
+}
+
+// addLiteralCompletions keeps the value completions with the given labels
+func (cp *CompletionProvider) addLiteralCompletions(items *[]CompletionItem, labels ...string) {
+	var values []CompletionItem
+	cp.addValueCompletions(&values)
+	for _, value := range values {
+		for _, label := range labels {
+			if value.Label == label {
+				*items = append(*items, value)
+			}
+		}
+	}
+}
+
 func (cp *CompletionProvider) addBindingCompletions(items *[]CompletionItem) {
 	operators := []struct {
 		text          string
@@ -221,14 +491,16 @@ func (cp *CompletionProvider) addValueCompletions(items *[]CompletionItem) {
 		{"null", "Null value", "null", "Represents empty/null value"},
 		{"true", "Boolean true", "true", "Boolean true value"},
 		{"false", "Boolean false", "false", "Boolean false value"},
-		{"\\", "String literal", "\\\n\t\\", "Multi-line string literal"},
-		{"@\\", "Localized string", "@\\\n\t\\", "Localized multi-line string"},
+		{"\\", "String literal", "\\\\\n\t\\\\$0", "Multi-line string literal"},
+		{"@\\", "Localized string", "@\\\\\n\t\\\\$0", "Localized multi-line string"},
 		{"*", "Dictionary marker", "*", "Marks property as dictionary"},
 	}
 
+	plainText := !cp.snippets()
+
 	for _, value := range specialValues {
 		insertText := value.insertText
-		if insertText == "" {
+		if insertText == "" || plainText {
 			insertText = value.text
 		}
 
@@ -241,7 +513,7 @@ func (cp *CompletionProvider) addValueCompletions(items *[]CompletionItem) {
 			Documentation: value.documentation,
 		}
 
-		if strings.Contains(insertText, "\n") {
+		if insertText != value.text {
 			item.InsertTextFormat = InsertTextFormatSnippet
 		}
 
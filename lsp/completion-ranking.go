@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// completionListLimit caps the items of one response; a capped list is
+// incomplete, so the client asks again as the user types
+const completionListLimit = 100
+
+// recentCompletionLimit is how many accepted labels are remembered
+const recentCompletionLimit = 50
+
+// CompletionAcceptedCommand is run by the client when an item is accepted
+// and feeds the recently used ranking
+const CompletionAcceptedCommand = "viewTree.completionAccepted"
+
+// rankedItem is a completion item with its ranking keys
+type rankedItem struct {
+	item      CompletionItem
+	match     int // 3 prefix, 2 prefix ignoring case, 1 fuzzy
+	recent    int // position in the recently used list, -1 when not used
+	group     string
+	proximity int // leading namespace segments shared with the document
+	usages    int
+}
+
+// ProvideCompletionList fuzzy matches the items against the word being
+// typed, ranks them and caps the list
+func (cp *CompletionProvider) ProvideCompletionList(document *TextDocument, position Position) (*CompletionList, error) {
+	items, err := cp.ProvideCompletionItems(document, position)
+	if err != nil {
+		return nil, err
+	}
+
+	typed := cp.typedWord(document.Text, position)
+	namespace := cp.documentNamespace(document)
+	usages := cp.componentUsages()
+
+	cp.recentMutex.Lock()
+	recent := make(map[string]int, len(cp.recent))
+	for i, label := range cp.recent {
+		recent[label] = i
+	}
+	cp.recentMutex.Unlock()
+
+	var ranked []rankedItem
+	for _, item := range items {
+		match := fuzzyMatch(typed, item.Label)
+		if match == 0 {
+			continue
+		}
+		entry := rankedItem{item: item, match: match, recent: -1, group: item.SortText}
+		if rank, ok := recent[item.Label]; ok {
+			entry.recent = rank
+		}
+		if len(entry.group) > 0 {
+			entry.group = entry.group[:1]
+		}
+		if item.Kind == CompletionItemKindClass {
+			entry.proximity = namespaceProximity(namespace, item.Label)
+			entry.usages = usages[item.Label]
+		}
+		ranked = append(ranked, entry)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		switch {
+		case a.match != b.match:
+			return a.match > b.match
+		case (a.recent >= 0) != (b.recent >= 0):
+			return a.recent >= 0
+		case a.recent != b.recent:
+			return a.recent < b.recent
+		case a.group != b.group:
+			return a.group < b.group
+		case a.proximity != b.proximity:
+			return a.proximity > b.proximity
+		case a.usages != b.usages:
+			return a.usages > b.usages
+		}
+		return a.item.Label < b.item.Label
+	})
+
+	list := &CompletionList{Items: []CompletionItem{}}
+	if len(ranked) > completionListLimit {
+		ranked = ranked[:completionListLimit]
+		list.IsIncomplete = true
+	}
+	for i, entry := range ranked {
+		item := entry.item
+		// Clients sort by SortText, keep the server ranking
+		item.SortText = fmt.Sprintf("%04d", i)
+		if item.Data != nil {
+			item.Command = &Command{
+				Title:     "",
+				Command:   CompletionAcceptedCommand,
+				Arguments: []interface{}{item.Label},
+			}
+		}
+		list.Items = append(list.Items, item)
+	}
+
+	log.Printf("[completion] Ranked %d of %d items for '%s', incomplete: %v", len(list.Items), len(items), typed, list.IsIncomplete)
+	return list, nil
+}
+
+// RecordAccepted moves an accepted label to the front of the recently used list
+func (cp *CompletionProvider) RecordAccepted(label string) {
+	cp.recentMutex.Lock()
+	defer cp.recentMutex.Unlock()
+
+	recent := []string{label}
+	for _, previous := range cp.recent {
+		if previous != label && len(recent) < recentCompletionLimit {
+			recent = append(recent, previous)
+		}
+	}
+	cp.recent = recent
+}
+
+// typedWord returns the part of the word before the cursor, or the operator
+// typed so far when there is no word
+func (cp *CompletionProvider) typedWord(content string, position Position) string {
+	lines := strings.Split(content, "\n")
+	if position.Line >= len(lines) {
+		return ""
+	}
+	line := lines[position.Line]
+	cursor := position.Character
+	if cursor > len(line) {
+		cursor = len(line)
+	}
+
+	if word := cp.parser.GetWordRangeAtPosition(content, position); word != nil && word.Start.Character < cursor {
+		return line[word.Start.Character:cursor]
+	}
+	start := cursor
+	for start > 0 && line[start-1] != ' ' && line[start-1] != '\t' {
+		start--
+	}
+	return line[start:cursor]
+}
+
+// fuzzyMatch rates how well a label matches the typed text, 0 when the
+// characters of the text do not appear in order in the label
+func fuzzyMatch(typed, label string) int {
+	switch {
+	case strings.HasPrefix(label, typed):
+		return 3
+	case strings.HasPrefix(strings.ToLower(label), strings.ToLower(typed)):
+		return 2
+	}
+
+	lowerLabel := strings.ToLower(label)
+	index := 0
+	for _, char := range strings.ToLower(typed) {
+		found := strings.IndexRune(lowerLabel[index:], char)
+		if found < 0 {
+			return 0
+		}
+		index += found + 1
+	}
+	return 1
+}
+
+// documentNamespace returns the name segments of the first component of the
+// document, or of its MAM path when it declares none
+func (cp *CompletionProvider) documentNamespace(document *TextDocument) []string {
+	if decls := BuildComponentDecls(ParseTree(document.Text), ""); len(decls) > 0 {
+		return strings.Split(strings.TrimPrefix(decls[0].Name, "$"), "_")
+	}
+
+	relative, err := filepath.Rel(cp.projectScanner.workspaceRoot, filepath.Dir(uriToPath(document.URI)))
+	if err != nil || strings.HasPrefix(relative, "..") || relative == "." {
+		return nil
+	}
+	return strings.Split(filepath.ToSlash(relative), "/")
+}
+
+// namespaceProximity counts the leading name segments a component shares
+// with the namespace, e.g. 2 for $my_app_button in $my_app_page
+func namespaceProximity(namespace []string, component string) int {
+	segments := strings.Split(strings.TrimPrefix(component, "$"), "_")
+	shared := 0
+	for shared < len(namespace) && shared < len(segments) && namespace[shared] == segments[shared] {
+		shared++
+	}
+	return shared
+}
+
+// componentUsages counts how often each component is extended or
+// instantiated in view.tree files, libraries included
+func (cp *CompletionProvider) componentUsages() map[string]int {
+	return cp.projectCounts().usages
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CompletionItemData is carried by component and property items so that
+// completionItem/resolve can fill in their documentation
+type CompletionItemData struct {
+	URI       string `json:"uri"`
+	Component string `json:"component"`
+	Property  string `json:"property,omitempty"`
+}
+
+// ResolveCompletionItem fills in the documentation of a component or
+// property item: TypeScript JSDoc, declared properties, the inheritance chain
+// and the declaring file
+func (cp *CompletionProvider) ResolveCompletionItem(item CompletionItem, data CompletionItemData) CompletionItem {
+	if data.Component == "" {
+		return item
+	}
+
+	var lines []string
+	if data.Property == "" {
+		lines = cp.componentDocumentation(data.Component)
+	} else {
+		lines = cp.propertyDocumentation(data.Component, data.Property)
+	}
+	if len(lines) > 0 {
+		item.Documentation = MarkupContent{
+			Kind:  MarkupKindMarkdown,
+			Value: strings.Join(lines, "\n"),
+		}
+	}
+	return item
+}
+
+func (cp *CompletionProvider) componentDocumentation(component string) []string {
+	var lines []string
+	chain := cp.typeInferrer.ClassChain(component)
+	if len(chain) > 1 {
+		lines = append(lines, "`"+strings.Join(chain, "` → `")+"`", "")
+	}
+
+	tsClass := cp.projectScanner.GetTsClass(component)
+	if tsClass != nil && tsClass.Doc != "" {
+		lines = append(lines, tsClass.Doc, "")
+	}
+
+	if decl := cp.projectScanner.GetComponentDecl(component); decl != nil {
+		if len(decl.Properties) > 0 {
+			properties := make([]string, len(decl.Properties))
+			for i, property := range decl.Properties {
+				properties[i] = "`" + property.Signature + "`"
+			}
+			lines = append(lines, "**Properties**: "+strings.Join(properties, ", "), "")
+		}
+		lines = append(lines, fmt.Sprintf("**File**: `%s`", cp.relativePath(decl.File)))
+	} else if tsClass != nil {
+		lines = append(lines, fmt.Sprintf("**File**: `%s`", cp.relativePath(tsClass.File)))
+	}
+	return lines
+}
+
+func (cp *CompletionProvider) propertyDocumentation(class, name string) []string {
+	var lines []string
+	if propertyType := cp.typeInferrer.PropertyType(class, name); propertyType.Kind != TypeUnknown {
+		lines = append(lines, fmt.Sprintf("**Type**: `%s`", propertyType), "")
+	}
+
+	if decl := cp.projectScanner.GetComponentDecl(class); decl != nil {
+		if property := decl.Property(name); property != nil {
+			source := property.Node.Text()
+			if content, err := os.ReadFile(decl.File); err == nil {
+				source = nodeSource(string(content), property.Node)
+			}
+			lines = append(lines, "```tree", source, "```")
+			lines = append(lines, fmt.Sprintf("**Declared in**: `%s` (`%s:%d`)", class, cp.relativePath(decl.File), property.Node.Line+1), "")
+		}
+	}
+
+	if tsClass := cp.projectScanner.GetTsClass(class); tsClass != nil {
+		if method := tsClass.Methods[name]; method != nil {
+			signature := fmt.Sprintf("%s(%s)", method.Name, method.Params)
+			if method.ReturnType != "" {
+				signature += ": " + method.ReturnType
+			}
+			lines = append(lines, fmt.Sprintf("**TypeScript** `%s` (`%s:%d`):", tsClass.Name, cp.relativePath(tsClass.File), method.Range.Start.Line+1))
+			lines = append(lines, "```typescript", signature, "```")
+			if method.Doc != "" {
+				lines = append(lines, method.Doc)
+			}
+		}
+	}
+	return lines
+}
+
+func (cp *CompletionProvider) relativePath(file string) string {
+	relative, err := filepath.Rel(cp.projectScanner.workspaceRoot, file)
+	if err != nil {
+		return file
+	}
+	return relative
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// childScaffoldProperties is how many properties a child scaffold sets
+const childScaffoldProperties = 2
+
+// addChildScaffolds offers a full child declaration for every component
+// matching the typed word, e.g. "<= Button_minor $mol_button_minor" with the
+// properties instances of the component override most often. Inside a list
+// the child is bound with "<=". At the top level of a body it is declared as
+// a property and an additional edit adds it to the "sub /" list of the
+// component.
+func (cp *CompletionProvider) addChildScaffolds(items *[]CompletionItem, inferrer *TypeInferrer, elementType *ValueType, owner *ComponentDecl, content, typed string) {
+	overrides := cp.overrideCounts()
+
+	for _, label := range cp.projectScanner.GetComponents() {
+		name := childName(label)
+		if name == "" || fuzzyMatch(typed, label) == 0 {
+			continue
+		}
+		if elementType != nil && elementType.Kind == TypeComponent &&
+			!inferrer.IsAssignable(&ValueType{Kind: TypeComponent, Component: label}, elementType) {
+			continue
+		}
+
+		component := cp.componentItem(label)
+		head := fmt.Sprintf("<= ${1:%s} %s", name, snippetEscape(component.Label))
+		plain := "<= " + name + " " + component.Label
+		var additional []TextEdit
+		if owner != nil {
+			name = uniquePropertyName(owner, name)
+			head = name + " " + snippetEscape(component.Label)
+			plain = name + " " + component.Label
+			if edit, ok := subListEdit(owner, content, name); ok {
+				additional = append(additional, edit)
+			}
+		}
+
+		snippet := head
+		placeholder := 2
+		for _, property := range cp.scaffoldProperties(inferrer, component.Label, overrides) {
+			local := strings.ToLower(name) + "_" + property.Name
+			if property.Mutable {
+				snippet += fmt.Sprintf("\n\t%s? <=> ${%d:%s}? ${%d:null}", property.Name, placeholder, local, placeholder+1)
+				plain += fmt.Sprintf("\n\t%s? <=> %s? null", property.Name, local)
+				placeholder += 2
+			} else {
+				snippet += fmt.Sprintf("\n\t%s <= ${%d:%s}", property.Name, placeholder, local)
+				plain += fmt.Sprintf("\n\t%s <= %s", property.Name, local)
+				placeholder++
+			}
+		}
+
+		item := CompletionItem{
+			Label:               component.Label,
+			LabelDetails:        &CompletionItemLabelDetails{Description: strings.SplitN(plain, "\n", 2)[0]},
+			Kind:                CompletionItemKindSnippet,
+			InsertText:          plain,
+			SortText:            component.SortText,
+			Detail:              "Child component",
+			AdditionalTextEdits: additional,
+			Data:                component.Data,
+		}
+		if cp.snippets() {
+			item.InsertText = snippet
+			item.InsertTextFormat = InsertTextFormatSnippet
+		}
+		*items = append(*items, item)
+	}
+}
+
+// scaffoldProperties picks the properties a child scaffold sets: the ones
+// instances of the component and its bases override most, falling back to
+// the first properties declared along the class chain
+func (cp *CompletionProvider) scaffoldProperties(inferrer *TypeInferrer, component string, overrides map[string]map[string]int) []*PropertyDecl {
+	counts := make(map[string]int)
+	var declared []*PropertyDecl
+	byName := make(map[string]*PropertyDecl)
+	for _, class := range inferrer.ClassChain(component) {
+		for name, count := range overrides[class] {
+			counts[name] += count
+		}
+		decl := inferrer.componentDecl(class)
+		if decl == nil {
+			continue
+		}
+		for _, property := range decl.Properties {
+			if property.Via == "" && byName[property.Name] == nil {
+				byName[property.Name] = property
+				declared = append(declared, property)
+			}
+		}
+	}
+
+	ranked := make([]*PropertyDecl, len(declared))
+	copy(ranked, declared)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return counts[ranked[i].Name] > counts[ranked[j].Name]
+	})
+	if len(ranked) > childScaffoldProperties {
+		ranked = ranked[:childScaffoldProperties]
+	}
+	return ranked
+}
+
+// overrideCounts counts, per component, how often instances override each
+// of its properties
+func (cp *CompletionProvider) overrideCounts() map[string]map[string]int {
+	return cp.projectCounts().overrides
+}
+
+// childName suggests a property name for an instance: "$mol_button_minor"
+// gives "Button_minor". It is empty for a name without letters after "$".
+func childName(component string) string {
+	name := strings.TrimPrefix(component, "$")
+	if name == "" {
+		return ""
+	}
+	if index := strings.Index(name, "_"); index >= 0 && index < len(name)-1 {
+		name = name[index+1:]
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// uniquePropertyName appends a number to a name the component already declares
+func uniquePropertyName(decl *ComponentDecl, name string) string {
+	unique := name
+	for i := 2; decl.Property(unique) != nil; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	return unique
+}
+
+// subListEdit returns the edit appending "<= name" to the "sub /" list of a
+// component declared in the document
+func subListEdit(decl *ComponentDecl, content, name string) (TextEdit, bool) {
+	sub := decl.Property("sub")
+	if sub == nil || sub.Via != "" || sub.Value == nil || !strings.HasPrefix(sub.Value.Name, "/") {
+		return TextEdit{}, false
+	}
+
+	lines := strings.Split(content, "\n")
+	last := sub.Node.Line
+	sub.Node.Walk(func(node *TreeNode) bool {
+		if node.Line > last {
+			last = node.Line
+		}
+		return true
+	})
+	if last >= len(lines) {
+		return TextEdit{}, false
+	}
+
+	line := strings.TrimRight(lines[last], "\r")
+	subLine := lines[sub.Node.Line]
+	indent := subLine[:len(subLine)-len(strings.TrimLeft(subLine, "\t"))]
+	end := Position{Line: last, Character: len(line)}
+	return TextEdit{
+		Range:   Range{Start: end, End: end},
+		NewText: "\n" + indent + "\t<= " + name,
+	}, true
+}
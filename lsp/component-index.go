@@ -0,0 +1,65 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// GetComponentDecl returns the view.tree declaration of a component, searching
+// this root, namespace peers and library roots in that order
+func (ps *ProjectScanner) GetComponentDecl(component string) *ComponentDecl {
+	for _, data := range ps.searchOrder(component) {
+		data.mutex.RLock()
+		decl := data.Declarations[component]
+		data.mutex.RUnlock()
+		if decl != nil {
+			return decl
+		}
+	}
+	return nil
+}
+
+// GetTsClass returns the TypeScript class of a component, if any
+func (ps *ProjectScanner) GetTsClass(component string) *TsClass {
+	for _, data := range ps.searchOrder(component) {
+		data.mutex.RLock()
+		class := data.TsClasses[component]
+		data.mutex.RUnlock()
+		if class != nil {
+			return class
+		}
+	}
+	return nil
+}
+
+// GetComponentDecls returns the view.tree declarations of this root sorted by name
+func (ps *ProjectScanner) GetComponentDecls() []*ComponentDecl {
+	data := ps.project()
+	data.mutex.RLock()
+	defer data.mutex.RUnlock()
+
+	decls := make([]*ComponentDecl, 0, len(data.Declarations))
+	for _, decl := range data.Declarations {
+		decls = append(decls, decl)
+	}
+	sort.Slice(decls, func(i, j int) bool {
+		return decls[i].Name < decls[j].Name
+	})
+	return decls
+}
+
+// BaseClass returns the base class of a component from its view.tree
+// declaration, falling back to the TypeScript class
+func (ps *ProjectScanner) BaseClass(component string) string {
+	if decl := ps.GetComponentDecl(component); decl != nil {
+		return decl.Base
+	}
+	if class := ps.GetTsClass(component); class != nil {
+		// "$.$my_app" in the $.$$ namespace extends the generated class itself
+		base := strings.TrimPrefix(class.Base, "$.")
+		if base != component && strings.HasPrefix(base, "$") {
+			return base
+		}
+	}
+	return ""
+}
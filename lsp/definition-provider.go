@@ -19,35 +19,46 @@ func NewDefinitionProvider(projectScanner *ProjectScanner) *DefinitionProvider {
 	}
 }
 
-func (dp *DefinitionProvider) ProvideDefinition(document *TextDocument, position Position) ([]Location, error) {
+// ProvideDefinition returns links from the word at the position to the nodes
+// declaring it. Only files that exist are linked, so the result may be empty.
+func (dp *DefinitionProvider) ProvideDefinition(document *TextDocument, position Position) ([]LocationLink, error) {
 	content := document.Text
+	documentFile := uriToPath(document.URI)
+	tree := ParseTree(content)
+	decls := BuildComponentDecls(tree, documentFile)
+	
+	// Localized strings jump to their entries in the locale files
+	if strings.HasSuffix(document.URI, ".view.tree") {
+		if localized := LocaleStringAt(decls, position); localized != nil {
+			return dp.findLocaleDefinitions(localized, documentFile), nil
+		}
+	}
+	
 	wordRange := dp.parser.GetWordRangeAtPosition(content, position)
 	
 	if wordRange == nil {
-		return []Location{}, nil
+		return []LocationLink{}, nil
 	}
 	
 	nodeName := dp.getTextInRange(content, *wordRange)
 	if nodeName == "" {
-		return []Location{}, nil
+		return []LocationLink{}, nil
 	}
 	
 	nodeType := dp.getNodeType(content, position, *wordRange)
-	documentURI := document.URI
+	origin := *wordRange
 	
 	switch nodeType {
 	case "root_class":
-		return dp.findRootClassDefinition(documentURI, nodeName)
+		return dp.findRootClassDefinition(origin, documentFile, nodeName), nil
 	case "class":
-		return dp.findClassDefinition(nodeName)
+		return dp.findClassDefinition(origin, nodeName, tree.NodeAt(position)), nil
 	case "comp":
-		return dp.findCompDefinition(documentURI, nodeName)
-	case "prop":
-		return dp.findPropDefinition(documentURI, nodeName)
-	case "sub_prop":
-		return dp.findSubPropDefinition(documentURI, position, nodeName)
+		return dp.findCompDefinition(origin, documentFile, nodeName), nil
+	case "prop", "sub_prop":
+		return dp.findPropDefinition(origin, decls, tree.NodeAt(position), documentFile, nodeName), nil
 	default:
-		return []Location{}, nil
+		return []LocationLink{}, nil
 	}
 }
 
@@ -95,289 +106,195 @@ func (dp *DefinitionProvider) getNodeType(content string, position Position, wor
 	return "sub_prop"
 }
 
-func (dp *DefinitionProvider) findRootClassDefinition(documentURI, nodeName string) ([]Location, error) {
-	// Find corresponding .ts file
-	filePath := dp.uriToFilePath(documentURI)
-	tsPath := strings.Replace(filePath, ".tree", ".ts", 1)
-	tsURI := dp.filePathToURI(tsPath)
-	
-	// Try to find class symbol in .ts file
-	location, err := dp.findClassSymbolInFile(tsURI, "$"+nodeName)
-	if err == nil && location != nil {
-		return []Location{*location}, nil
-	}
-	
-	// If no specific class found, return beginning of file (always return location like in reference)
-	locationRange := Range{
-		Start: Position{Line: 0, Character: 0},
-		End:   Position{Line: 0, Character: 0},
-	}
-	return []Location{{URI: tsURI, Range: locationRange}}, nil
+// findRootClassDefinition links the component declared by the document to
+// its TypeScript class
+func (dp *DefinitionProvider) findRootClassDefinition(origin Range, documentFile, nodeName string) []LocationLink {
+	links := []LocationLink{}
+	if class := dp.tsClass(dp.className(nodeName), documentFile); class != nil {
+		links = dp.appendLink(links, origin, class.File, class.Range)
+	}
+	return links
 }
 
-func (dp *DefinitionProvider) findClassDefinition(nodeName string) ([]Location, error) {
-	parts := strings.Split(nodeName, "_")
-	workspaceRoot := dp.projectScanner.workspaceRoot
-	
-	if len(parts) == 0 {
-		return []Location{}, nil
+// findClassDefinition links a component to its view.tree root node and its
+// TypeScript class, skipping the node the request starts from
+func (dp *DefinitionProvider) findClassDefinition(origin Range, nodeName string, node *TreeNode) []LocationLink {
+	links := []LocationLink{}
+	component := dp.className(nodeName)
+	
+	viewTreeFile := ""
+	if decl := dp.projectScanner.GetComponentDecl(component); decl != nil {
+		// A root node is the declaration itself
+		if node == nil || node.Parent == nil || node.Parent.Parent != nil {
+			links = dp.appendLink(links, origin, decl.File, decl.Node.Range)
+		}
+		viewTreeFile = decl.File
+	} else if decl := dp.findDeclInMamPath(component); decl != nil {
+		links = dp.appendLink(links, origin, decl.File, decl.Node.Range)
+		viewTreeFile = decl.File
 	}
 	
-	lastPart := parts[len(parts)-1]
-	firstCharRange := Range{
-		Start: Position{Line: 0, Character: 0},
-		End:   Position{Line: 0, Character: 0},
+	if class := dp.tsClass(component, viewTreeFile); class != nil {
+		links = dp.appendLink(links, origin, class.File, class.Range)
 	}
 	
-	// First path: workspaceRoot/parts.join("/"), lastPart + ".view.tree"
-	viewTreePath1 := filepath.Join(append([]string{workspaceRoot}, append(parts, lastPart+".view.tree")...)...)
-	if _, err := os.Stat(viewTreePath1); err == nil {
-		uri := dp.filePathToURI(viewTreePath1)
-		return []Location{{URI: uri, Range: firstCharRange}}, nil
+	return links
+}
+
+// tsClass returns the TypeScript class of a component. The index holds a
+// limited number of TypeScript files, so a class it misses is read from the
+// .view.ts file next to the view.tree file declaring the component.
+func (dp *DefinitionProvider) tsClass(component, viewTreeFile string) *TsClass {
+	if class := dp.projectScanner.GetTsClass(component); class != nil {
+		return class
 	}
 	
-	// Second path: workspaceRoot/[...parts, lastPart].join("/"), lastPart + ".view.tree"
-	viewTreePath2 := filepath.Join(append([]string{workspaceRoot}, append(append(parts, lastPart), lastPart+".view.tree")...)...)
-	if _, err := os.Stat(viewTreePath2); err == nil {
-		uri := dp.filePathToURI(viewTreePath2)
-		return []Location{{URI: uri, Range: firstCharRange}}, nil
+	tsPath := strings.Replace(viewTreeFile, ".tree", ".ts", 1)
+	if tsPath == viewTreeFile {
+		return nil
 	}
-	
-	// Try to find in project data (equivalent to workspace symbols)
-	componentFile := dp.projectScanner.GetComponentFile(nodeName)
-	if componentFile != "" {
-		uri := dp.filePathToURI(componentFile)
-		return []Location{{URI: uri, Range: firstCharRange}}, nil
+	content, err := os.ReadFile(tsPath)
+	if err != nil {
+		return nil
 	}
-	
-	// Always return first path location (even if file doesn't exist) like in reference
-	uri := dp.filePathToURI(viewTreePath1)
-	return []Location{{URI: uri, Range: firstCharRange}}, nil
+	for _, class := range ParseTsClasses(string(content), tsPath) {
+		if class.Name == component {
+			return class
+		}
+	}
+	return nil
 }
 
-func (dp *DefinitionProvider) findCompDefinition(documentURI, nodeName string) ([]Location, error) {
-	// Find corresponding .css.ts file
-	filePath := dp.uriToFilePath(documentURI)
-	cssPath := strings.Replace(filePath, ".tree", ".css.ts", 1)
-	cssURI := dp.filePathToURI(cssPath)
+// findDeclInMamPath looks for a component that is not indexed yet where MAM
+// puts it: $mol_button in mol/button/button.view.tree
+func (dp *DefinitionProvider) findDeclInMamPath(component string) *ComponentDecl {
+	parts := strings.Split(strings.TrimPrefix(component, "$"), "_")
+	lastPart := parts[len(parts)-1]
+	workspaceRoot := dp.projectScanner.workspaceRoot
 	
-	if _, err := os.Stat(cssPath); err == nil {
-		// Try to find the CSS class definition
-		content, err := os.ReadFile(cssPath)
-		if err == nil {
-			cssRule := dp.extractCssRule(string(content), nodeName)
-			if cssRule != nil {
-				return []Location{*cssRule}, nil
-			}
+	candidates := []string{
+		filepath.Join(append([]string{workspaceRoot}, append(parts, lastPart+".view.tree")...)...),
+		filepath.Join(append([]string{workspaceRoot}, append(append(parts, lastPart), lastPart+".view.tree")...)...),
+	}
+	for _, candidate := range candidates {
+		content, err := os.ReadFile(candidate)
+		if err != nil {
+			continue
 		}
-		
-		// If no specific match, return beginning of file
-		locationRange := Range{
-			Start: Position{Line: 0, Character: 0},
-			End:   Position{Line: 0, Character: 0},
+		for _, decl := range BuildComponentDecls(ParseTree(string(content)), candidate) {
+			if decl.Name == component {
+				return decl
+			}
 		}
-		return []Location{{URI: cssURI, Range: locationRange}}, nil
 	}
-	
-	return []Location{}, nil
+	return nil
 }
 
-func (dp *DefinitionProvider) findPropDefinition(documentURI, nodeName string) ([]Location, error) {
-	// Get className from position 0,1 (like in reference)
-	content, err := dp.getDocumentContent(documentURI)
-	if err != nil {
-		return []Location{}, err
+// findCompDefinition links a sub-component name to its rule in the .css.ts
+// file next to the document
+func (dp *DefinitionProvider) findCompDefinition(origin Range, documentFile, nodeName string) []LocationLink {
+	links := []LocationLink{}
+	cssPath := strings.Replace(documentFile, ".tree", ".css.ts", 1)
+	if cssPath == documentFile {
+		return links
 	}
 	
-	// Get word at position 0,1 and add $ prefix
-	className := dp.getClassNameAtPosition01(content)
-	if className == "" {
-		return []Location{}, nil
+	content, err := os.ReadFile(cssPath)
+	if err != nil {
+		return links
 	}
-	
-	// Find corresponding .ts file
-	filePath := dp.uriToFilePath(documentURI)
-	tsPath := strings.Replace(filePath, ".tree", ".ts", 1)
-	tsURI := dp.filePathToURI(tsPath)
-	
-	// Find property in .ts file
-	propLocation, err := dp.findPropertyInFile(tsURI, className, nodeName)
-	if err == nil && propLocation != nil {
-		return []Location{*propLocation}, nil
+	if cssRule := dp.extractCssRule(dp.filePathToURI(cssPath), string(content), propertyBaseName(nodeName)); cssRule != nil {
+		links = dp.appendLink(links, origin, cssPath, cssRule.Range)
 	}
-	
-	// Always fallback to comp definition if no propSymbol found (like in reference)
-	return dp.findCompDefinition(documentURI, nodeName)
-}
-
-func (dp *DefinitionProvider) findSubPropDefinition(documentURI string, position Position, nodeName string) ([]Location, error) {
-	// This is a simplified version - in the original code this uses source maps
-	// For now, we'll try to find it as a regular property
-	return dp.findPropDefinition(documentURI, nodeName)
+	return links
 }
 
-func (dp *DefinitionProvider) findClassSymbolInFile(fileURI, className string) (*Location, error) {
-	filePath := dp.uriToFilePath(fileURI)
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, err
-	}
-	
-	// Look for class definition
-	escapedClassName := regexp.QuoteMeta(className)
-	classRegex := regexp.MustCompile(`class\s+` + escapedClassName + `\b`)
-	match := classRegex.FindIndex(content)
-	
-	if match != nil {
-		lines := strings.Split(string(content[:match[0]]), "\n")
-		line := len(lines) - 1
-		var character int
-		if len(lines) > 0 {
-			character = len(lines[len(lines)-1])
+// findPropDefinition links a property to the nearest class of its owner that
+// declares it, in view.tree and TypeScript, and to its .css.ts rule
+func (dp *DefinitionProvider) findPropDefinition(origin Range, decls []*ComponentDecl, node *TreeNode, documentFile, nodeName string) []LocationLink {
+	links := []LocationLink{}
+	decl := ComponentAt(decls, origin.Start.Line)
+	if decl == nil || node == nil || node.IsData() {
+		return links
+	}
+	
+	name := propertyBaseName(nodeName)
+	inferrer := NewTypeInferrer(dp.projectScanner).ForDocument(decls)
+	for _, class := range inferrer.ClassChain(PropertyClass(decl, node)) {
+		viewTreeFile := ""
+		if classDecl := inferrer.componentDecl(class); classDecl != nil {
+			if property := classDecl.Property(name); property != nil && property.Node != node {
+				links = dp.appendLink(links, origin, classDecl.File, property.Node.Range)
+			}
+			viewTreeFile = classDecl.File
 		}
-		
-		r := Range{
-			Start: Position{Line: line, Character: character},
-			End:   Position{Line: line, Character: character + len(className)},
+		if tsClass := dp.tsClass(class, viewTreeFile); tsClass != nil {
+			if method := tsClass.Methods[name]; method != nil {
+				links = dp.appendLink(links, origin, tsClass.File, method.Range)
+			}
+		}
+		if len(links) > 0 {
+			break
 		}
-		
-		return &Location{URI: fileURI, Range: r}, nil
 	}
 	
-	return nil, nil
+	return append(links, dp.findCompDefinition(origin, documentFile, nodeName)...)
 }
 
-func (dp *DefinitionProvider) findPropertyInFile(fileURI, className, propertyName string) (*Location, error) {
-	filePath := dp.uriToFilePath(fileURI)
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, err
-	}
-	
-	contentStr := string(content)
-	
-	// Look for property definition within class
-	escapedClassName := regexp.QuoteMeta(className)
-	classRegex := regexp.MustCompile(`class\s+` + escapedClassName + `[^{]*\{([^}]*(?:\{[^}]*\}[^}]*)*)\}`)
-	classMatch := classRegex.FindStringSubmatch(contentStr)
-	
-	if len(classMatch) > 1 {
-		classContent := classMatch[1]
-		escapedPropertyName := regexp.QuoteMeta(propertyName)
-		propRegex := regexp.MustCompile(`\b` + escapedPropertyName + `\s*[(:=]`)
-		propMatch := propRegex.FindStringIndex(classContent)
-		
-		if propMatch != nil {
-			// Find the position in the original content
-			classStart := strings.Index(contentStr, classContent)
-			propStart := classStart + propMatch[0]
-			
-			beforeMatch := contentStr[:propStart]
-			lines := strings.Split(beforeMatch, "\n")
-			line := len(lines) - 1
-			var character int
-			if len(lines) > 0 {
-				character = len(lines[len(lines)-1])
-			}
-			
-			r := Range{
-				Start: Position{Line: line, Character: character},
-				End:   Position{Line: line, Character: character + len(propertyName)},
-			}
-			
-			return &Location{URI: fileURI, Range: r}, nil
+// findLocaleDefinitions returns the entry of the key in every locale file
+// that translates it
+func (dp *DefinitionProvider) findLocaleDefinitions(localized *LocaleString, documentFile string) []LocationLink {
+	links := []LocationLink{}
+	for _, file := range LocaleFilesFor(documentFile) {
+		if keyRange, ok := file.KeyRange(localized.Key); ok {
+			links = dp.appendLink(links, localized.Range(), file.File, keyRange)
 		}
 	}
-	
-	return nil, nil
+	return links
 }
 
-func (dp *DefinitionProvider) extractCssRule(cssContent, className string) *Location {
+// appendLink adds a link to a node of a file, unless the file does not exist
+func (dp *DefinitionProvider) appendLink(links []LocationLink, origin Range, filePath string, target Range) []LocationLink {
+	if filePath == "" {
+		return links
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		return links
+	}
+	originRange := origin
+	return append(links, LocationLink{
+		OriginSelectionRange: &originRange,
+		TargetURI:            dp.filePathToURI(filePath),
+		TargetRange:          target,
+		TargetSelectionRange: target,
+	})
+}
+
+func (dp *DefinitionProvider) extractCssRule(cssURI, cssContent, className string) *Location {
 	// Look for CSS class definition in TypeScript CSS-in-JS format
 	escapedClassName := regexp.QuoteMeta(className)
-	classRegex := regexp.MustCompile(escapedClassName + `\s*:\s*\{`)
+	classRegex := regexp.MustCompile(`\b` + escapedClassName + `\s*:\s*\{`)
 	match := classRegex.FindStringIndex(cssContent)
 	
 	if match != nil {
 		lines := strings.Split(cssContent[:match[0]], "\n")
 		line := len(lines) - 1
-		var character int
-		if len(lines) > 0 {
-			character = len(lines[len(lines)-1])
-		}
+		character := len(lines[line])
 		
 		r := Range{
 			Start: Position{Line: line, Character: character},
 			End:   Position{Line: line, Character: character + len(className)},
 		}
 		
-		// We need a file URI - this should be constructed from the CSS file path
-		// For now, return a location with empty URI as we'd need the actual file URI
-		return &Location{URI: "", Range: r}
+		return &Location{URI: cssURI, Range: r}
 	}
 	
 	return nil
 }
 
-func (dp *DefinitionProvider) getDocumentContent(uri string) (string, error) {
-	filePath := dp.uriToFilePath(uri)
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return "", err
-	}
-	return string(content), nil
-}
-
-func (dp *DefinitionProvider) getCurrentComponentFromContent(content string) string {
-	lines := strings.Split(content, "\n")
-	
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if !strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, " ") && strings.HasPrefix(trimmed, "$") {
-			fields := strings.Fields(trimmed)
-			if len(fields) > 0 && strings.HasPrefix(fields[0], "$") {
-				return fields[0]
-			}
-		}
-	}
-	
-	return ""
-}
-
-func (dp *DefinitionProvider) getClassNameAtPosition01(content string) string {
-	// Get word at position 0,1 and add $ prefix (like in reference)
-	lines := strings.Split(content, "\n")
-	if len(lines) == 0 {
-		return ""
-	}
-	
-	line := lines[0]
-	if len(line) <= 1 {
-		return ""
-	}
-	
-	// Find word starting at position 1
-	start := 1
-	end := start
-	
-	// Move end forwards to find word end
-	for end < len(line) && dp.isWordCharacter(rune(line[end])) {
-		end++
-	}
-	
-	if start == end {
-		return ""
-	}
-	
-	nodeName := line[start:end]
-	return "$" + nodeName
-}
-
-func (dp *DefinitionProvider) isWordCharacter(char rune) bool {
-	return (char >= 'a' && char <= 'z') ||
-		(char >= 'A' && char <= 'Z') ||
-		(char >= '0' && char <= '9') ||
-		char == '_' || char == '$' || char == '?' || char == '*'
+// className returns the $ class name of a word, with or without the "$"
+func (dp *DefinitionProvider) className(nodeName string) string {
+	return "$" + strings.TrimPrefix(nodeName, "$")
 }
 
 func (dp *DefinitionProvider) getTextInRange(content string, r Range) string {
@@ -394,18 +311,6 @@ func (dp *DefinitionProvider) getTextInRange(content string, r Range) string {
 	return line[r.Start.Character:r.End.Character]
 }
 
-func (dp *DefinitionProvider) uriToFilePath(uri string) string {
-	// Simple URI to file path conversion
-	if strings.HasPrefix(uri, "file://") {
-		return strings.TrimPrefix(uri, "file://")
-	}
-	return uri
-}
-
 func (dp *DefinitionProvider) filePathToURI(filePath string) string {
-	// Simple file path to URI conversion
-	if !strings.HasPrefix(filePath, "file://") {
-		return "file://" + filePath
-	}
-	return filePath
+	return pathToURI(filePath)
 }
\ No newline at end of file
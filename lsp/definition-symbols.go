@@ -0,0 +1,150 @@
+package main
+
+import (
+	"strings"
+)
+
+// definitionSymbol is the component or property a navigation request starts from
+type definitionSymbol struct {
+	origin   Range
+	node     *TreeNode
+	decl     *ComponentDecl // component whose body holds the node
+	class    string         // referenced component, or the class owning the property
+	property string         // "" for component references
+	inferrer *TypeInferrer
+}
+
+// symbolAt resolves the node at the position. Operators, literals and
+// dictionary keys are not symbols.
+func (dp *DefinitionProvider) symbolAt(document *TextDocument, position Position) *definitionSymbol {
+	tree := ParseTree(document.Text)
+	decls := BuildComponentDecls(tree, uriToPath(document.URI))
+	node := tree.NodeAt(position)
+	if node == nil || node.IsData() {
+		return nil
+	}
+
+	symbol := &definitionSymbol{
+		origin:   node.Range,
+		node:     node,
+		decl:     ComponentAt(decls, node.Line),
+		inferrer: NewTypeInferrer(dp.projectScanner).ForDocument(decls),
+	}
+
+	// "/$mol_view" and "*$mol_view" refer to the item class
+	name := node.Name
+	if len(name) > 1 && (name[0] == '/' || name[0] == '*') && name[1] == '$' {
+		name = name[1:]
+	}
+	if strings.HasPrefix(name, "$") {
+		symbol.class = name
+		return symbol
+	}
+
+	if symbol.decl == nil || !isPropertyName(name) || (node.Parent != nil && strings.HasPrefix(node.Parent.Name, "*")) {
+		return nil
+	}
+	symbol.class = PropertyClass(symbol.decl, node)
+	symbol.property = propertyBaseName(name)
+	return symbol
+}
+
+// isPropertyName reports whether a word can name a property, as opposed to
+// operators and literals
+func isPropertyName(word string) bool {
+	if word == "" || word == "null" || word == "true" || word == "false" || isNumberLiteral(word) {
+		return false
+	}
+	first := word[0]
+	return first == '_' || (first >= 'a' && first <= 'z') || (first >= 'A' && first <= 'Z')
+}
+
+// ProvideDeclaration links a property usage to the nearest view.tree node
+// declaring it, and a component reference to its view.tree root
+func (dp *DefinitionProvider) ProvideDeclaration(document *TextDocument, position Position) []LocationLink {
+	links := []LocationLink{}
+	symbol := dp.symbolAt(document, position)
+	if symbol == nil {
+		return links
+	}
+
+	if symbol.property == "" {
+		if decl := symbol.inferrer.componentDecl(symbol.class); decl != nil && decl.Node != symbol.node {
+			links = dp.appendLink(links, symbol.origin, decl.File, decl.Node.Range)
+		}
+		return links
+	}
+
+	for _, class := range symbol.inferrer.ClassChain(symbol.class) {
+		decl := symbol.inferrer.componentDecl(class)
+		if decl == nil {
+			continue
+		}
+		if property := decl.Property(symbol.property); property != nil && property.Node != symbol.node {
+			return dp.appendLink(links, symbol.origin, decl.File, property.Node.Range)
+		}
+	}
+	return links
+}
+
+// ProvideTypeDefinition links a property to the component class of its value,
+// e.g. "Selector" to "$mol_select" for "<= Selector $mol_select". Lists of
+// components link to the item class.
+func (dp *DefinitionProvider) ProvideTypeDefinition(document *TextDocument, position Position) []LocationLink {
+	links := []LocationLink{}
+	symbol := dp.symbolAt(document, position)
+	if symbol == nil || symbol.property == "" {
+		return links
+	}
+
+	valueType := symbol.inferrer.PropertyType(symbol.class, symbol.property)
+	for valueType.Kind == TypeList || valueType.Kind == TypeDict {
+		valueType = valueType.Element
+	}
+	if valueType.Kind != TypeComponent {
+		return links
+	}
+
+	if decl := symbol.inferrer.componentDecl(valueType.Component); decl != nil {
+		links = dp.appendLink(links, symbol.origin, decl.File, decl.Node.Range)
+	}
+	if class := dp.projectScanner.GetTsClass(valueType.Component); class != nil {
+		links = dp.appendLink(links, symbol.origin, class.File, class.Range)
+	}
+	return links
+}
+
+// ProvideImplementation links a property to the TypeScript methods overriding
+// it in the owning class and its subclasses, and a component to the
+// TypeScript classes of itself and its subclasses
+func (dp *DefinitionProvider) ProvideImplementation(document *TextDocument, position Position) []LocationLink {
+	links := []LocationLink{}
+	symbol := dp.symbolAt(document, position)
+	if symbol == nil {
+		return links
+	}
+
+	subclasses := symbol.inferrer.SubclassIndex()
+	seen := map[string]bool{symbol.class: true}
+	queue := []string{symbol.class}
+	for len(queue) > 0 {
+		class := queue[0]
+		queue = queue[1:]
+
+		if tsClass := dp.projectScanner.GetTsClass(class); tsClass != nil {
+			if symbol.property == "" {
+				links = dp.appendLink(links, symbol.origin, tsClass.File, tsClass.Range)
+			} else if method := tsClass.Methods[symbol.property]; method != nil {
+				links = dp.appendLink(links, symbol.origin, tsClass.File, method.Range)
+			}
+		}
+
+		for _, subclass := range subclasses[class] {
+			if !seen[subclass] {
+				seen[subclass] = true
+				queue = append(queue, subclass)
+			}
+		}
+	}
+	return links
+}
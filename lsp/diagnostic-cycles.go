@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validateCycles reports bindings that depend on themselves and components
+// that inherit from themselves. Both overflow the stack at runtime.
+func (dp *DiagnosticProvider) validateCycles(decls []*ComponentDecl, documentURI string) []Diagnostic {
+	var diagnostics []Diagnostic
+	inferrer := NewTypeInferrer(dp.projectScanner).ForDocument(decls)
+
+	for _, decl := range decls {
+		diagnostics = append(diagnostics, dp.bindingCycles(decl, documentURI)...)
+		if diagnostic := dp.inheritanceCycle(inferrer, decl, documentURI); diagnostic != nil {
+			diagnostics = append(diagnostics, *diagnostic)
+		}
+	}
+
+	return diagnostics
+}
+
+// bindingCycles finds cycles in the graph of "<=" and "<=>" bindings between
+// the properties of one component, e.g. a <= b, b <= c, c <= a
+func (dp *DiagnosticProvider) bindingCycles(decl *ComponentDecl, documentURI string) []Diagnostic {
+	edges := make(map[string][]*BindingDecl)
+	var order []string
+	for _, binding := range decl.Bindings {
+		if binding.Instance != nil || binding.Target == "" || binding.Kind == BindingOutput {
+			continue
+		}
+		if _, exists := edges[binding.Target]; !exists {
+			order = append(order, binding.Target)
+		}
+		edges[binding.Target] = append(edges[binding.Target], binding)
+	}
+
+	var diagnostics []Diagnostic
+	reported := make(map[string]bool)
+	state := make(map[string]int) // 0 unvisited, 1 on the stack, 2 done
+	var stack []*BindingDecl
+
+	var visit func(property string)
+	visit = func(property string) {
+		state[property] = 1
+		for _, binding := range edges[property] {
+			switch state[binding.Source] {
+			case 0:
+				stack = append(stack, binding)
+				visit(binding.Source)
+				stack = stack[:len(stack)-1]
+			case 1:
+				cycle := append([]*BindingDecl{}, stack...)
+				cycle = append(cycle, binding)
+				// Drop the bindings leading into the cycle
+				for len(cycle) > 0 && cycle[0].Target != binding.Source {
+					cycle = cycle[1:]
+				}
+				if key := cycleKey(cycle); !reported[key] {
+					reported[key] = true
+					diagnostics = append(diagnostics, bindingCycleDiagnostic(cycle, documentURI))
+				}
+			}
+		}
+		state[property] = 2
+	}
+
+	for _, property := range order {
+		if state[property] == 0 {
+			visit(property)
+		}
+	}
+
+	return diagnostics
+}
+
+// cycleKey identifies a cycle regardless of the property it was entered from
+func cycleKey(cycle []*BindingDecl) string {
+	names := make([]string, len(cycle))
+	start := 0
+	for i, binding := range cycle {
+		names[i] = binding.Target
+		if binding.Target < cycle[start].Target {
+			start = i
+		}
+	}
+	return strings.Join(append(names[start:], names[:start]...), " ")
+}
+
+func bindingCycleDiagnostic(cycle []*BindingDecl, documentURI string) Diagnostic {
+	path := []string{cycle[0].Target}
+	var related []DiagnosticRelatedInformation
+	first := cycle[0]
+	for _, binding := range cycle {
+		path = append(path, binding.Source)
+		related = append(related, DiagnosticRelatedInformation{
+			Location: Location{URI: documentURI, Range: binding.SourceNode.Range},
+			Message:  fmt.Sprintf("'%s' %s '%s'", binding.Target, binding.Kind, binding.Source),
+		})
+		if binding.Node.Line < first.Node.Line {
+			first = binding
+		}
+	}
+
+	return Diagnostic{
+		Severity:           DiagnosticSeverityError,
+		Range:              first.SourceNode.Range,
+		Message:            "Binding cycle: " + strings.Join(path, " <= "),
+		Code:               RuleBindingCycle,
+		Source:             "view.tree",
+		RelatedInformation: related,
+	}
+}
+
+// inheritanceCycle follows the base classes of a component across the project
+// and reports when they lead back to it
+func (dp *DiagnosticProvider) inheritanceCycle(inferrer *TypeInferrer, decl *ComponentDecl, documentURI string) *Diagnostic {
+	if decl.BaseNode == nil {
+		return nil
+	}
+
+	path := []string{decl.Name}
+	seen := map[string]bool{decl.Name: true}
+	for class := decl.Base; class != ""; class = inferrer.baseClass(class) {
+		path = append(path, class)
+		if class == decl.Name {
+			break
+		}
+		if seen[class] {
+			// A cycle further up the chain is reported in its own file
+			return nil
+		}
+		seen[class] = true
+	}
+	if path[len(path)-1] != decl.Name {
+		return nil
+	}
+
+	var related []DiagnosticRelatedInformation
+	for _, class := range path[:len(path)-1] {
+		classDecl := inferrer.componentDecl(class)
+		if classDecl == nil || classDecl.BaseNode == nil {
+			continue
+		}
+		uri := documentURI
+		if class != decl.Name {
+			uri = pathToURI(classDecl.File)
+		}
+		related = append(related, DiagnosticRelatedInformation{
+			Location: Location{URI: uri, Range: classDecl.BaseNode.Range},
+			Message:  fmt.Sprintf("'%s' extends '%s'", class, classDecl.Base),
+		})
+	}
+
+	return &Diagnostic{
+		Severity:           DiagnosticSeverityError,
+		Range:              decl.BaseNode.Range,
+		Message:            "Inheritance cycle: " + strings.Join(path, " -> "),
+		Code:               RuleInheritanceCycle,
+		Source:             "view.tree",
+		RelatedInformation: related,
+	}
+}
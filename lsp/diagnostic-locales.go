@@ -0,0 +1,28 @@
+package main
+
+// validateLocales reports "@" strings that some of the locale files next to
+// the document do not translate
+func (dp *DiagnosticProvider) validateLocales(decls []*ComponentDecl, documentFile string) []Diagnostic {
+	var diagnostics []Diagnostic
+	files := LocaleFilesFor(documentFile)
+	if len(files) == 0 {
+		return diagnostics
+	}
+
+	for _, localized := range LocaleStrings(decls) {
+		missing := localized.MissingIn(files)
+		if len(missing) == 0 {
+			continue
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			Severity: DiagnosticSeverityWarning,
+			Range:    localized.Range(),
+			Message:  "Localized string '" + localized.Key + "' has no translation in: " + localeLanguages(missing),
+			Code:     RuleMissingTranslation,
+			Source:   "view.tree",
+			Data:     map[string]string{"key": localized.Key},
+		})
+	}
+
+	return diagnostics
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// validateMembers reports overrides of child instance properties, "=>"
+// exports and "^" overrides that name properties the class does not have.
+// Classes whose inheritance chain is not fully indexed are skipped.
+func (dp *DiagnosticProvider) validateMembers(decls []*ComponentDecl) []Diagnostic {
+	var diagnostics []Diagnostic
+	inferrer := NewTypeInferrer(dp.projectScanner).ForDocument(decls)
+
+	for _, decl := range decls {
+		for _, instance := range decl.Instances {
+			for _, override := range instance.Overrides {
+				message := "'%s' has no property '%s'"
+				if override.Value != nil && override.Value.Name == BindingOutput {
+					message = "'%s' has no property '%s' to export with =>"
+				}
+				if diagnostic := dp.checkMember(inferrer, instance.Component, override.Name, override.Node.Range, message); diagnostic != nil {
+					diagnostics = append(diagnostics, *diagnostic)
+				}
+			}
+		}
+
+		if decl.Base == "" {
+			continue
+		}
+		for _, property := range decl.Properties {
+			if property.Via != "" || !inheritsValue(property.Value) {
+				continue
+			}
+			message := "'%s' has no property '%s' to override with ^"
+			if diagnostic := dp.checkMember(inferrer, decl.Base, property.Name, property.Node.Range, message); diagnostic != nil {
+				diagnostics = append(diagnostics, *diagnostic)
+			}
+		}
+	}
+
+	return diagnostics
+}
+
+// checkMember returns a diagnostic when class certainly lacks the property
+func (dp *DiagnosticProvider) checkMember(inferrer *TypeInferrer, class, property string, r Range, message string) *Diagnostic {
+	if found, complete := inferrer.HasProperty(class, property); found || !complete {
+		return nil
+	}
+
+	text := fmt.Sprintf(message, class, property)
+	var data interface{}
+	if suggestion := closestName(property, inferrer.PropertyNames(class)); suggestion != "" {
+		text += fmt.Sprintf(". Did you mean '%s'?", suggestion)
+		data = map[string]string{"suggestion": suggestion}
+	}
+
+	return &Diagnostic{
+		Severity: DiagnosticSeverityError,
+		Range:    r,
+		Message:  text,
+		Code:     RuleUnknownMember,
+		Source:   "view.tree",
+		Data:     data,
+	}
+}
+
+// inheritsValue reports whether a value is "^" or a list or dictionary that
+// includes the inherited items with "^"
+func inheritsValue(value *TreeNode) bool {
+	if value == nil {
+		return false
+	}
+	if value.Name == BindingOverride {
+		return true
+	}
+	if strings.HasPrefix(value.Name, "/") || strings.HasPrefix(value.Name, "*") {
+		for _, item := range value.Children {
+			if item.Name == BindingOverride {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PropertyNames returns the properties of a class and its bases, sorted
+func (ti *TypeInferrer) PropertyNames(class string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	for _, name := range ti.ClassChain(class) {
+		if decl := ti.componentDecl(name); decl != nil {
+			for _, property := range decl.Properties {
+				add(property.Name)
+			}
+		}
+		if tsClass := ti.projectScanner.GetTsClass(name); tsClass != nil {
+			for method := range tsClass.Methods {
+				add(method)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// closestName returns the candidate with the smallest edit distance to name,
+// or "" when none is close enough to be a likely typo
+func closestName(name string, candidates []string) string {
+	best := ""
+	bestDistance := len(name)/3 + 1
+	for _, candidate := range candidates {
+		if distance := levenshtein(name, candidate); distance <= bestDistance && (best == "" || distance < levenshtein(name, best)) {
+			best = candidate
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
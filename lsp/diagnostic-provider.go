@@ -6,15 +6,40 @@ import (
 	"strings"
 )
 
+// Diagnostic rule codes. Every diagnostic carries one of them so that rules can
+// be silenced with "- vt-disable" and "- vt-disable-next-line" comments.
+const (
+	RuleParseError         = "VT001"
+	RuleUnknownComponent   = "VT002"
+	RuleDuplicateComponent = "VT003"
+	RuleDuplicateProperty  = "VT004"
+	RuleInvalidName        = "VT005"
+	RuleReservedName       = "VT006"
+	RuleInvalidBinding     = "VT007"
+	RuleIndentation        = "VT008"
+	RuleMixedIndentation   = "VT009"
+	RuleUnusedSuppression  = "VT010"
+	RuleTypeMismatch       = "VT011"
+	RuleListItemType       = "VT012"
+	RuleUnknownMember      = "VT013"
+	RuleUnusedProperty     = "VT014"
+	RuleDeadComponent      = "VT015"
+	RuleBindingCycle       = "VT016"
+	RuleInheritanceCycle   = "VT017"
+	RuleMissingTranslation = "VT018"
+)
+
 type DiagnosticProvider struct {
 	projectScanner *ProjectScanner
 	parser         *ViewTreeParser
+	usageAnalyzer  *UsageAnalyzer
 }
 
 func NewDiagnosticProvider(projectScanner *ProjectScanner) *DiagnosticProvider {
 	return &DiagnosticProvider{
 		projectScanner: projectScanner,
 		parser:         NewViewTreeParser(),
+		usageAnalyzer:  NewUsageAnalyzer(projectScanner),
 	}
 }
 
@@ -36,12 +61,15 @@ func (dp *DiagnosticProvider) ProvideDiagnostics(document *TextDocument) ([]Diag
 			Severity: dp.mapSeverity(parseError.Severity),
 			Range:    parseError.Range,
 			Message:  parseError.Message,
+			Code:     RuleParseError,
 			Source:   "view.tree",
 		})
 	}
 
+	commentLines := dp.parser.CommentLines(content)
+
 	// Validate syntax
-	syntaxDiagnostics := dp.validateSyntax(content, document.URI)
+	syntaxDiagnostics := dp.validateSyntax(content, document.URI, commentLines)
 	diagnostics = append(diagnostics, syntaxDiagnostics...)
 
 	// Validate components
@@ -53,17 +81,64 @@ func (dp *DiagnosticProvider) ProvideDiagnostics(document *TextDocument) ([]Diag
 	diagnostics = append(diagnostics, propertyDiagnostics...)
 
 	// Validate indentation
-	indentationDiagnostics := dp.validateIndentation(content)
+	indentationDiagnostics := dp.validateIndentation(content, commentLines)
 	diagnostics = append(diagnostics, indentationDiagnostics...)
 
 	// Validate bindings
-	bindingDiagnostics := dp.validateBindings(content)
+	bindingDiagnostics := dp.validateBindings(content, commentLines)
 	diagnostics = append(diagnostics, bindingDiagnostics...)
+	
+	// Type-check bindings and typed lists
+	documentFile := uriToPath(document.URI)
+	decls := BuildComponentDecls(ParseTree(content), documentFile)
+	typeDiagnostics := dp.validateTypes(decls)
+	diagnostics = append(diagnostics, typeDiagnostics...)
+	
+	// Resolve overridden and exported members against the project index
+	memberDiagnostics := dp.validateMembers(decls)
+	diagnostics = append(diagnostics, memberDiagnostics...)
+	
+	// Detect binding and inheritance cycles
+	cycleDiagnostics := dp.validateCycles(decls, document.URI)
+	diagnostics = append(diagnostics, cycleDiagnostics...)
+	
+	// Check translations of localized strings
+	localeDiagnostics := dp.validateLocales(decls, documentFile)
+	diagnostics = append(diagnostics, localeDiagnostics...)
+	
+	// Flag unused properties and dead components
+	usageDiagnostics := dp.usageAnalyzer.DocumentDiagnostics(decls, documentFile)
+	diagnostics = append(diagnostics, usageDiagnostics...)
+
+	// Drop suppressed diagnostics and report unused suppressions
+	diagnostics = dp.applySuppressions(diagnostics, parseResult.Comments, content, commentLines)
+
+	// Apply configured rule severities
+	diagnostics = dp.applyRuleSeverities(diagnostics)
 
 	return diagnostics, nil
 }
 
-func (dp *DiagnosticProvider) validateSyntax(content, documentURI string) []Diagnostic {
+// applyRuleSeverities drops disabled rules and overrides severities from settings
+func (dp *DiagnosticProvider) applyRuleSeverities(diagnostics []Diagnostic) []Diagnostic {
+	settings := dp.projectScanner.Settings()
+	var result []Diagnostic
+
+	for _, diagnostic := range diagnostics {
+		code, _ := diagnostic.Code.(string)
+		if settings.IsRuleDisabled(code) {
+			continue
+		}
+		if severity, ok := settings.RuleSeverity(code); ok {
+			diagnostic.Severity = severity
+		}
+		result = append(result, diagnostic)
+	}
+
+	return result
+}
+
+func (dp *DiagnosticProvider) validateSyntax(content, documentURI string, commentLines map[int]bool) []Diagnostic {
 	var diagnostics []Diagnostic
 	lines := strings.Split(content, "\n")
 
@@ -74,7 +149,7 @@ func (dp *DiagnosticProvider) validateSyntax(content, documentURI string) []Diag
 		trimmed := strings.TrimSpace(line)
 
 		// Skip empty lines and comments
-		if trimmed == "" || strings.HasPrefix(trimmed, "//") {
+		if trimmed == "" || commentLines[lineIndex] {
 			continue
 		}
 
@@ -94,6 +169,7 @@ func (dp *DiagnosticProvider) validateSyntax(content, documentURI string) []Diag
 						Severity: DiagnosticSeverityError,
 						Range:    r,
 						Message:  fmt.Sprintf("Invalid component name: %s. Component names must start with $ followed by letters, numbers, or underscores.", componentName),
+						Code:     RuleInvalidName,
 						Source:   "view.tree",
 					})
 				}
@@ -101,7 +177,7 @@ func (dp *DiagnosticProvider) validateSyntax(content, documentURI string) []Diag
 		}
 
 		// Check for mixing tabs and spaces
-		if len(line) > 0 && !strings.HasPrefix(trimmed, "//") {
+		if len(line) > 0 {
 			leadingWhitespace := regexp.MustCompile(`^(\s*)`).FindString(line)
 			hasTab := strings.Contains(leadingWhitespace, "\t")
 			hasSpace := strings.Contains(leadingWhitespace, " ")
@@ -115,6 +191,7 @@ func (dp *DiagnosticProvider) validateSyntax(content, documentURI string) []Diag
 					Severity: DiagnosticSeverityWarning,
 					Range:    r,
 					Message:  "Mixed tabs and spaces in indentation. Use either tabs or spaces consistently.",
+					Code:     RuleMixedIndentation,
 					Source:   "view.tree",
 				})
 			}
@@ -133,6 +210,7 @@ func (dp *DiagnosticProvider) validateSyntax(content, documentURI string) []Diag
 					Severity: DiagnosticSeverityError,
 					Range:    r,
 					Message:  "Binding operator must be followed by a property name.",
+					Code:     RuleInvalidBinding,
 					Source:   "view.tree",
 				})
 			}
@@ -146,7 +224,6 @@ func (dp *DiagnosticProvider) validateSyntax(content, documentURI string) []Diag
 
 func (dp *DiagnosticProvider) validateComponents(components []ParsedComponent, documentURI string) []Diagnostic {
 	var diagnostics []Diagnostic
-	projectData := dp.projectScanner.GetProjectData()
 
 	// Create a set of components defined in current document
 	currentDocComponents := make(map[string]bool)
@@ -154,13 +231,12 @@ func (dp *DiagnosticProvider) validateComponents(components []ParsedComponent, d
 		currentDocComponents[comp.Name] = true
 	}
 
+	reportedDuplicates := make(map[string]bool)
 	for _, component := range components {
 		componentName := component.Name
 
-		// Check if component exists in project
-		projectData.mutex.RLock()
-		hasComponent := projectData.Components[componentName]
-		projectData.mutex.RUnlock()
+		// Check if component exists in project or in a peer root
+		hasComponent := dp.projectScanner.HasComponent(componentName)
 
 		// Also check if component is defined in current document
 		hasComponentInCurrentDoc := currentDocComponents[componentName]
@@ -170,6 +246,7 @@ func (dp *DiagnosticProvider) validateComponents(components []ParsedComponent, d
 				Severity: DiagnosticSeverityWarning,
 				Range:    component.Range,
 				Message:  fmt.Sprintf("Component '%s' not found in project. Consider defining it or check the spelling.", componentName),
+				Code:     RuleUnknownComponent,
 				Source:   "view.tree",
 			})
 		}
@@ -182,7 +259,8 @@ func (dp *DiagnosticProvider) validateComponents(components []ParsedComponent, d
 			}
 		}
 
-		if duplicateCount > 1 {
+		if duplicateCount > 1 && !reportedDuplicates[componentName] {
+			reportedDuplicates[componentName] = true
 			// Mark all duplicates except the first one
 			isFirst := true
 			for _, otherComponent := range components {
@@ -195,6 +273,7 @@ func (dp *DiagnosticProvider) validateComponents(components []ParsedComponent, d
 						Severity: DiagnosticSeverityError,
 						Range:    otherComponent.Range,
 						Message:  fmt.Sprintf("Duplicate component definition: %s", componentName),
+						Code:     RuleDuplicateComponent,
 						Source:   "view.tree",
 					})
 				}
@@ -209,6 +288,7 @@ func (dp *DiagnosticProvider) validateProperties(components []ParsedComponent, c
 	var diagnostics []Diagnostic
 
 	for _, component := range components {
+		reportedDuplicates := make(map[string]bool)
 		for _, property := range component.Properties {
 			propertyName := property.Name
 
@@ -219,6 +299,7 @@ func (dp *DiagnosticProvider) validateProperties(components []ParsedComponent, c
 					Severity: DiagnosticSeverityError,
 					Range:    property.Range,
 					Message:  fmt.Sprintf("Invalid property name: %s. Property names must start with a letter, $, or underscore.", propertyName),
+					Code:     RuleInvalidName,
 					Source:   "view.tree",
 				})
 			}
@@ -231,6 +312,7 @@ func (dp *DiagnosticProvider) validateProperties(components []ParsedComponent, c
 						Severity: DiagnosticSeverityError,
 						Range:    property.Range,
 						Message:  fmt.Sprintf("Reserved property name: %s. Choose a different name.", propertyName),
+						Code:     RuleReservedName,
 						Source:   "view.tree",
 					})
 					break
@@ -245,7 +327,8 @@ func (dp *DiagnosticProvider) validateProperties(components []ParsedComponent, c
 				}
 			}
 
-			if duplicateCount > 1 {
+			if duplicateCount > 1 && !reportedDuplicates[propertyName] {
+				reportedDuplicates[propertyName] = true
 				// Mark all duplicates except the first one
 				isFirst := true
 				for _, otherProperty := range component.Properties {
@@ -258,6 +341,7 @@ func (dp *DiagnosticProvider) validateProperties(components []ParsedComponent, c
 							Severity: DiagnosticSeverityWarning,
 							Range:    otherProperty.Range,
 							Message:  fmt.Sprintf("Duplicate property: %s", propertyName),
+							Code:     RuleDuplicateProperty,
 							Source:   "view.tree",
 						})
 					}
@@ -282,6 +366,7 @@ func (dp *DiagnosticProvider) validateProperties(components []ParsedComponent, c
 								Severity: DiagnosticSeverityError,
 								Range:    r,
 								Message:  fmt.Sprintf("Invalid binding target: %s", bindingTarget),
+								Code:     RuleInvalidBinding,
 								Source:   "view.tree",
 							})
 						}
@@ -294,7 +379,7 @@ func (dp *DiagnosticProvider) validateProperties(components []ParsedComponent, c
 	return diagnostics
 }
 
-func (dp *DiagnosticProvider) validateIndentation(content string) []Diagnostic {
+func (dp *DiagnosticProvider) validateIndentation(content string, commentLines map[int]bool) []Diagnostic {
 	var diagnostics []Diagnostic
 	lines := strings.Split(content, "\n")
 	lastNonEmptyIndent := 0
@@ -306,7 +391,7 @@ func (dp *DiagnosticProvider) validateIndentation(content string) []Diagnostic {
 		trimmed := strings.TrimSpace(line)
 
 		// Skip empty lines and comments
-		if trimmed == "" || strings.HasPrefix(trimmed, "//") {
+		if trimmed == "" || commentLines[lineIndex] {
 			continue
 		}
 
@@ -322,6 +407,7 @@ func (dp *DiagnosticProvider) validateIndentation(content string) []Diagnostic {
 				Severity: DiagnosticSeverityError,
 				Range:    r,
 				Message:  "Component definitions should not be indented.",
+				Code:     RuleIndentation,
 				Source:   "view.tree",
 			})
 		}
@@ -336,6 +422,7 @@ func (dp *DiagnosticProvider) validateIndentation(content string) []Diagnostic {
 				Severity: DiagnosticSeverityError,
 				Range:    r,
 				Message:  "Properties must be indented under their component.",
+				Code:     RuleIndentation,
 				Source:   "view.tree",
 			})
 		}
@@ -350,6 +437,7 @@ func (dp *DiagnosticProvider) validateIndentation(content string) []Diagnostic {
 				Severity: DiagnosticSeverityWarning,
 				Range:    r,
 				Message:  "Indentation increased by more than one level. This might indicate a structural issue.",
+				Code:     RuleIndentation,
 				Source:   "view.tree",
 			})
 		}
@@ -362,7 +450,7 @@ func (dp *DiagnosticProvider) validateIndentation(content string) []Diagnostic {
 	return diagnostics
 }
 
-func (dp *DiagnosticProvider) validateBindings(content string) []Diagnostic {
+func (dp *DiagnosticProvider) validateBindings(content string, commentLines map[int]bool) []Diagnostic {
 	var diagnostics []Diagnostic
 	lines := strings.Split(content, "\n")
 
@@ -373,7 +461,7 @@ func (dp *DiagnosticProvider) validateBindings(content string) []Diagnostic {
 		trimmed := strings.TrimSpace(line)
 
 		// Skip empty lines and comments
-		if trimmed == "" || strings.HasPrefix(trimmed, "//") {
+		if trimmed == "" || commentLines[lineIndex] {
 			continue
 		}
 
@@ -402,6 +490,7 @@ func (dp *DiagnosticProvider) validateBindings(content string) []Diagnostic {
 						Severity: DiagnosticSeverityError,
 						Range:    r,
 						Message:  check.message,
+						Code:     RuleInvalidBinding,
 						Source:   "view.tree",
 					})
 				}
@@ -437,6 +526,7 @@ func (dp *DiagnosticProvider) validateBindings(content string) []Diagnostic {
 				Severity: DiagnosticSeverityError,
 				Range:    r,
 				Message:  "Cannot use both <= and <=> operators in the same line.",
+				Code:     RuleInvalidBinding,
 				Source:   "view.tree",
 			})
 		}
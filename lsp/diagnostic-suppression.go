@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	suppressFileDirective     = "vt-disable"
+	suppressNextLineDirective = "vt-disable-next-line"
+)
+
+// suppressionDirective is a parsed "- vt-disable ..." comment.
+type suppressionDirective struct {
+	comment    ParsedComment
+	fileLevel  bool
+	targetLine int             // line silenced by vt-disable-next-line
+	codes      []string        // empty means every rule
+	usedCodes  map[string]bool // codes that actually silenced something
+	used       bool
+}
+
+var suppressionCodeRegex = regexp.MustCompile(`^VT\d+$`)
+
+// parseSuppressions extracts suppression directives from comment nodes.
+// Supported forms are "- vt-disable [codes]" for the whole file and
+// "- vt-disable-next-line [codes]" for the next non-comment line.
+func (dp *DiagnosticProvider) parseSuppressions(comments []ParsedComment, content string, commentLines map[int]bool) []*suppressionDirective {
+	var directives []*suppressionDirective
+	lines := strings.Split(content, "\n")
+
+	for _, comment := range comments {
+		fields := strings.FieldsFunc(comment.Text, func(r rune) bool {
+			return r == ' ' || r == '\t' || r == ','
+		})
+		if len(fields) == 0 {
+			continue
+		}
+
+		directive := &suppressionDirective{
+			comment:    comment,
+			targetLine: -1,
+			usedCodes:  make(map[string]bool),
+		}
+
+		switch fields[0] {
+		case suppressFileDirective:
+			directive.fileLevel = true
+		case suppressNextLineDirective:
+			directive.targetLine = dp.findNextCodeLine(lines, comment.Line, commentLines)
+		default:
+			continue
+		}
+
+		for _, code := range fields[1:] {
+			code = strings.ToUpper(code)
+			if suppressionCodeRegex.MatchString(code) {
+				directive.codes = append(directive.codes, code)
+			}
+		}
+
+		directives = append(directives, directive)
+	}
+
+	return directives
+}
+
+// findNextCodeLine returns the first non-empty, non-comment line after the given line.
+func (dp *DiagnosticProvider) findNextCodeLine(lines []string, afterLine int, commentLines map[int]bool) int {
+	for i := afterLine + 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" || commentLines[i] {
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+// applySuppressions removes diagnostics silenced by suppression comments and
+// reports directives that did not silence anything.
+func (dp *DiagnosticProvider) applySuppressions(diagnostics []Diagnostic, comments []ParsedComment, content string, commentLines map[int]bool) []Diagnostic {
+	directives := dp.parseSuppressions(comments, content, commentLines)
+	if len(directives) == 0 {
+		return diagnostics
+	}
+
+	var result []Diagnostic
+	for _, diagnostic := range diagnostics {
+		suppressed := false
+		for _, directive := range directives {
+			if directive.matches(diagnostic) {
+				suppressed = true
+			}
+		}
+		if !suppressed {
+			result = append(result, diagnostic)
+		}
+	}
+
+	for _, directive := range directives {
+		result = append(result, directive.unusedDiagnostics()...)
+	}
+
+	return result
+}
+
+// matches reports whether the directive silences the diagnostic and records the usage.
+func (sd *suppressionDirective) matches(diagnostic Diagnostic) bool {
+	if !sd.fileLevel && diagnostic.Range.Start.Line != sd.targetLine {
+		return false
+	}
+
+	code, _ := diagnostic.Code.(string)
+	if len(sd.codes) == 0 {
+		sd.used = true
+		return true
+	}
+
+	for _, suppressed := range sd.codes {
+		if suppressed == code {
+			sd.used = true
+			sd.usedCodes[code] = true
+			return true
+		}
+	}
+
+	return false
+}
+
+func (sd *suppressionDirective) unusedDiagnostics() []Diagnostic {
+	directiveName := suppressNextLineDirective
+	if sd.fileLevel {
+		directiveName = suppressFileDirective
+	}
+
+	if len(sd.codes) == 0 {
+		if sd.used {
+			return nil
+		}
+		return []Diagnostic{sd.unusedDiagnostic(fmt.Sprintf("Unused %s directive: no diagnostics were suppressed.", directiveName))}
+	}
+
+	var unusedCodes []string
+	for _, code := range sd.codes {
+		if !sd.usedCodes[code] {
+			unusedCodes = append(unusedCodes, code)
+		}
+	}
+	if len(unusedCodes) == 0 {
+		return nil
+	}
+
+	return []Diagnostic{sd.unusedDiagnostic(fmt.Sprintf("Unused %s directive for %s.", directiveName, strings.Join(unusedCodes, ", ")))}
+}
+
+func (sd *suppressionDirective) unusedDiagnostic(message string) Diagnostic {
+	return Diagnostic{
+		Severity: DiagnosticSeverityWarning,
+		Range:    sd.comment.Range,
+		Message:  message,
+		Code:     RuleUnusedSuppression,
+		Source:   "view.tree",
+	}
+}
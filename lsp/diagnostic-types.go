@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validateTypes checks bindings and typed lists against the inferred types
+func (dp *DiagnosticProvider) validateTypes(decls []*ComponentDecl) []Diagnostic {
+	var diagnostics []Diagnostic
+	inferrer := NewTypeInferrer(dp.projectScanner).ForDocument(decls)
+
+	for _, decl := range decls {
+		for _, binding := range decl.Bindings {
+			// Members read by "=>" are checked by validateMembers
+			if binding.Target == "" || binding.Kind == BindingOutput {
+				continue
+			}
+
+			if diagnostic := dp.checkBindingType(inferrer, decl, binding); diagnostic != nil {
+				diagnostics = append(diagnostics, *diagnostic)
+			}
+		}
+
+		diagnostics = append(diagnostics, dp.checkListItems(inferrer, decl)...)
+	}
+
+	return diagnostics
+}
+
+// checkBindingType compares the type of the bound source with the type the
+// target property has in the class it is inherited from
+func (dp *DiagnosticProvider) checkBindingType(inferrer *TypeInferrer, decl *ComponentDecl, binding *BindingDecl) *Diagnostic {
+	targetClass := decl.Base
+	if binding.Instance != nil {
+		targetClass = binding.Instance.Component
+	}
+	if targetClass == "" {
+		return nil
+	}
+
+	expected := inferrer.PropertyType(targetClass, binding.Target)
+	actual := inferrer.PropertyType(decl.Name, binding.Source)
+	if inferrer.IsAssignable(actual, expected) {
+		return nil
+	}
+
+	return &Diagnostic{
+		Severity: DiagnosticSeverityError,
+		Range:    binding.SourceNode.Range,
+		Message:  fmt.Sprintf("Type mismatch: '%s' is %s but '%s' of %s expects %s", binding.Source, actual, binding.Target, targetClass, expected),
+		Code:     RuleTypeMismatch,
+		Source:   "view.tree",
+	}
+}
+
+// checkListItems reports items of "/type" lists that do not match the type
+func (dp *DiagnosticProvider) checkListItems(inferrer *TypeInferrer, decl *ComponentDecl) []Diagnostic {
+	var diagnostics []Diagnostic
+
+	body := decl.Node
+	if decl.BaseNode != nil {
+		body = decl.BaseNode
+	}
+
+	body.Walk(func(node *TreeNode) bool {
+		if node.IsData() || !strings.HasPrefix(node.Name, "/") {
+			return true
+		}
+		element := ParseElementType(strings.TrimPrefix(node.Name, "/"))
+		if !element.IsKnown() {
+			return true
+		}
+
+		for _, item := range node.Children {
+			if item.Name == BindingOverride {
+				continue
+			}
+			itemType := inferrer.ValueType(decl.Name, item)
+			if inferrer.IsAssignable(itemType, element) {
+				continue
+			}
+			diagnostics = append(diagnostics, Diagnostic{
+				Severity: DiagnosticSeverityError,
+				Range:    item.Range,
+				Message:  fmt.Sprintf("List item of type %s does not match element type %s", itemType, element),
+				Code:     RuleListItemType,
+				Source:   "view.tree",
+			})
+		}
+		return true
+	})
+
+	return diagnostics
+}
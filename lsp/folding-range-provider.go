@@ -0,0 +1,131 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// FoldingRangeProvider folds view.tree documents along the indentation tree:
+// root components, properties with children, "/" lists and "*" dictionaries,
+// runs of "\" string lines and "-" comment blocks
+type FoldingRangeProvider struct {
+	projectScanner *ProjectScanner
+	parser         *ViewTreeParser
+}
+
+func NewFoldingRangeProvider(projectScanner *ProjectScanner) *FoldingRangeProvider {
+	return &FoldingRangeProvider{
+		projectScanner: projectScanner,
+		parser:         NewViewTreeParser(),
+	}
+}
+
+// ProvideFoldingRanges returns the folding ranges of a document sorted by
+// start line. Clients folding whole lines only get no characters, the others
+// keep the first line of a range visible up to its end. A positive limit
+// caps the number of ranges.
+func (fp *FoldingRangeProvider) ProvideFoldingRanges(document *TextDocument, lineFoldingOnly bool, limit int) []FoldingRange {
+	lines := strings.Split(document.Text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, "\r")
+	}
+
+	// Nodes later on a line are nested in the first one, fold the line once
+	ends := make(map[int]int)
+	ParseTree(document.Text).Walk(func(node *TreeNode) bool {
+		if node.Parent == nil || node.IsData() {
+			return true
+		}
+		if end := lastLine(node); end > node.Line && end > ends[node.Line] {
+			ends[node.Line] = end
+		}
+		return true
+	})
+
+	ranges := []FoldingRange{}
+	add := func(start, end int, kind string) {
+		folding := FoldingRange{StartLine: start, EndLine: end, Kind: kind}
+		if !lineFoldingOnly {
+			startCharacter, endCharacter := len(lines[start]), len(lines[end])
+			folding.StartCharacter = &startCharacter
+			folding.EndCharacter = &endCharacter
+		}
+		ranges = append(ranges, folding)
+	}
+
+	for start, end := range ends {
+		add(start, end, "")
+	}
+	for _, block := range fp.stringBlocks(lines) {
+		add(block[0], block[1], "")
+	}
+	for _, block := range fp.commentBlocks(document.Text, lines) {
+		add(block[0], block[1], FoldingRangeKindComment)
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].StartLine != ranges[j].StartLine {
+			return ranges[i].StartLine < ranges[j].StartLine
+		}
+		return ranges[i].EndLine > ranges[j].EndLine
+	})
+	if limit > 0 && len(ranges) > limit {
+		ranges = ranges[:limit]
+	}
+	return ranges
+}
+
+// stringBlocks returns the first and last lines of runs of at least two
+// "\" lines at the same indentation
+func (fp *FoldingRangeProvider) stringBlocks(lines []string) [][2]int {
+	var blocks [][2]int
+	start := -1
+	for i := 0; i <= len(lines); i++ {
+		continues := i < len(lines) && start >= 0 && strings.HasPrefix(strings.TrimLeft(lines[i], "\t"), "\\") &&
+			fp.parser.getIndentLevel(lines[i]) == fp.parser.getIndentLevel(lines[start])
+		if continues {
+			continue
+		}
+		if start >= 0 && i-1 > start {
+			blocks = append(blocks, [2]int{start, i - 1})
+		}
+		start = -1
+		if i < len(lines) && strings.HasPrefix(strings.TrimLeft(lines[i], "\t"), "\\") {
+			start = i
+		}
+	}
+	return blocks
+}
+
+// commentBlocks returns the first and last lines of consecutive comment
+// lines, nested lines included, spanning at least two lines
+func (fp *FoldingRangeProvider) commentBlocks(content string, lines []string) [][2]int {
+	comments := fp.parser.CommentLines(content)
+	var blocks [][2]int
+	start := -1
+	for i := 0; i <= len(lines); i++ {
+		if i < len(lines) && comments[i] {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && i-1 > start {
+			blocks = append(blocks, [2]int{start, i - 1})
+		}
+		start = -1
+	}
+	return blocks
+}
+
+// lastLine returns the last line a node or its descendants are on
+func lastLine(node *TreeNode) int {
+	last := node.Line
+	node.Walk(func(descendant *TreeNode) bool {
+		if descendant.Line > last {
+			last = descendant.Line
+		}
+		return true
+	})
+	return last
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// compileGlob converts a glob pattern into a regular expression.
+// "**" matches across directories, "*" and "?" match within a single path segment.
+func compileGlob(pattern string) *regexp.Regexp {
+	var builder strings.Builder
+	builder.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		char := pattern[i]
+		switch char {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// "**/" matches zero or more directories
+					i++
+					builder.WriteString("(?:.*/)?")
+				} else {
+					builder.WriteString(".*")
+				}
+			} else {
+				builder.WriteString("[^/]*")
+			}
+		case '?':
+			builder.WriteString("[^/]")
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+
+	builder.WriteString("$")
+	return regexp.MustCompile(builder.String())
+}
+
+// matchGlob reports whether a slash-separated relative path matches the pattern.
+// Patterns without a slash are matched against every path segment, so "-*"
+// excludes any directory whose name starts with a dash.
+func matchGlob(pattern, relPath string) bool {
+	pattern = strings.TrimSuffix(pattern, "/")
+	relPath = filepath.ToSlash(relPath)
+	re := compileGlob(strings.TrimPrefix(pattern, "/"))
+
+	if !strings.Contains(pattern, "/") {
+		for _, segment := range strings.Split(relPath, "/") {
+			if re.MatchString(segment) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return re.MatchString(relPath)
+}
@@ -12,17 +12,25 @@ import (
 type HoverProvider struct {
 	projectScanner *ProjectScanner
 	parser         *ViewTreeParser
+	typeInferrer   *TypeInferrer
 }
 
 func NewHoverProvider(projectScanner *ProjectScanner) *HoverProvider {
 	return &HoverProvider{
 		projectScanner: projectScanner,
 		parser:         NewViewTreeParser(),
+		typeInferrer:   NewTypeInferrer(projectScanner),
 	}
 }
 
 func (hp *HoverProvider) ProvideHover(document *TextDocument, position Position) (*Hover, error) {
 	content := document.Text
+	
+	// Localized strings: "@ \text"
+	if hover := hp.getLocaleHover(document, position); hover != nil {
+		return hover, nil
+	}
+	
 	wordRange := hp.parser.GetWordRangeAtPosition(content, position)
 	
 	if wordRange == nil {
@@ -48,9 +56,9 @@ func (hp *HoverProvider) ProvideHover(document *TextDocument, position Position)
 	case "comp":
 		hoverContent, err = hp.getCssClassHover(nodeName, documentURI)
 	case "prop":
-		hoverContent = hp.getPropertyHover(nodeName, content)
+		hoverContent = hp.getPropertyHover(nodeName, content, position)
 	case "sub_prop":
-		hoverContent = hp.getSubPropertyHover(nodeName, content)
+		hoverContent = hp.getSubPropertyHover(nodeName, content, position)
 	default:
 		hoverContent = hp.getGenericHover(nodeName)
 	}
@@ -70,6 +78,45 @@ func (hp *HoverProvider) ProvideHover(document *TextDocument, position Position)
 	}, nil
 }
 
+// getLocaleHover shows the translations of the localized string at the position
+func (hp *HoverProvider) getLocaleHover(document *TextDocument, position Position) *Hover {
+	if !strings.HasSuffix(document.URI, ".view.tree") {
+		return nil
+	}
+	documentFile := uriToPath(document.URI)
+	localized := LocaleStringAt(BuildComponentDecls(ParseTree(document.Text), documentFile), position)
+	if localized == nil {
+		return nil
+	}
+	
+	var markdownContent []string
+	markdownContent = append(markdownContent, fmt.Sprintf("**Localized string**: `%s`", localized.Key))
+	markdownContent = append(markdownContent, "")
+	markdownContent = append(markdownContent, fmt.Sprintf("- *source*: %s", localized.Text))
+	
+	files := LocaleFilesFor(documentFile)
+	for _, file := range files {
+		if translation, ok := file.Entries[localized.Key]; ok {
+			markdownContent = append(markdownContent, fmt.Sprintf("- `%s`: %s", file.Language, translation))
+		} else {
+			markdownContent = append(markdownContent, fmt.Sprintf("- `%s`: *missing*", file.Language))
+		}
+	}
+	if len(files) == 0 {
+		markdownContent = append(markdownContent, "")
+		markdownContent = append(markdownContent, "*No `*.locale=*.json` files next to this view.tree*")
+	}
+	
+	localizedRange := localized.Range()
+	return &Hover{
+		Contents: MarkupContent{
+			Kind:  MarkupKindMarkdown,
+			Value: strings.Join(markdownContent, "\n"),
+		},
+		Range: &localizedRange,
+	}
+}
+
 func (hp *HoverProvider) getNodeType(content string, position Position, wordRange Range) string {
 	lines := strings.Split(content, "\n")
 	if position.Line >= len(lines) {
@@ -115,11 +162,7 @@ func (hp *HoverProvider) getNodeType(content string, position Position, wordRang
 }
 
 func (hp *HoverProvider) getComponentHover(componentName, documentURI string) (*MarkupContent, error) {
-	projectData := hp.projectScanner.GetProjectData()
-	
-	projectData.mutex.RLock()
-	hasComponent := projectData.Components[componentName]
-	projectData.mutex.RUnlock()
+	hasComponent := hp.projectScanner.HasComponent(componentName)
 	
 	var markdownContent []string
 	
@@ -162,12 +205,25 @@ func (hp *HoverProvider) getComponentHover(componentName, documentURI string) (*
 		markdownContent = append(markdownContent, "")
 	}
 	
+	if hp.projectScanner.IsLibraryComponent(componentName) {
+		markdownContent = append(markdownContent, "*Library component (read-only)*")
+		markdownContent = append(markdownContent, "")
+	}
+	
+	verbosity := hp.projectScanner.Settings().Hover.Verbosity
+	if verbosity == HoverVerbosityMinimal {
+		return &MarkupContent{
+			Kind:  MarkupKindMarkdown,
+			Value: strings.Join(markdownContent, "\n"),
+		}, nil
+	}
+	
 	// Component properties
 	properties := hp.projectScanner.GetPropertiesForComponent(componentName)
 	if len(properties) > 0 {
 		markdownContent = append(markdownContent, "**Properties**:")
 		maxProps := 10
-		if len(properties) > maxProps {
+		if len(properties) > maxProps && verbosity != HoverVerbosityVerbose {
 			for _, prop := range properties[:maxProps] {
 				markdownContent = append(markdownContent, fmt.Sprintf("- `%s`", prop))
 			}
@@ -212,7 +268,7 @@ func (hp *HoverProvider) getCssClassHover(className, documentURI string) (*Marku
 	markdownContent = append(markdownContent, "")
 	
 	// Try to find CSS definition
-	filePath := hp.uriToFilePath(documentURI)
+	filePath := uriToPath(documentURI)
 	cssPath := strings.Replace(filePath, ".view.tree", ".css.ts", 1)
 	
 	if _, err := os.Stat(cssPath); err == nil {
@@ -241,35 +297,55 @@ func (hp *HoverProvider) getCssClassHover(className, documentURI string) (*Marku
 	}, nil
 }
 
-func (hp *HoverProvider) getPropertyHover(propertyName, content string) *MarkupContent {
-	currentComponent := hp.parser.GetCurrentComponent(content, Position{Line: 0, Character: 0})
+func (hp *HoverProvider) getPropertyHover(propertyName, content string, position Position) *MarkupContent {
+	tree := ParseTree(content)
+	decls := BuildComponentDecls(tree, "")
+	inferrer := hp.typeInferrer.ForDocument(decls)
+	name := propertyBaseName(propertyName)
 	var markdownContent []string
 	
 	markdownContent = append(markdownContent, fmt.Sprintf("**Property**: `%s`", propertyName))
 	markdownContent = append(markdownContent, "")
 	
-	if currentComponent != "" {
-		markdownContent = append(markdownContent, fmt.Sprintf("**Component**: `%s`", currentComponent))
-		markdownContent = append(markdownContent, "")
-	}
-	
-	// Find property context in the current file
-	propertyContext := hp.findPropertyContext(propertyName, content)
-	if propertyContext != nil {
-		if propertyContext.BindingType != "" {
-			markdownContent = append(markdownContent, fmt.Sprintf("**Binding**: `%s`", propertyContext.BindingType))
-			markdownContent = append(markdownContent, "")
+	decl := ComponentAt(decls, position.Line)
+	node := tree.NodeAt(position)
+	class := ""
+	if decl != nil {
+		class = decl.Name
+		if node != nil {
+			class = PropertyClass(decl, node)
 		}
-		if propertyContext.Value != "" {
-			markdownContent = append(markdownContent, fmt.Sprintf("**Value**: `%s`", propertyContext.Value))
+		markdownContent = append(markdownContent, fmt.Sprintf("**Component**: `%s`", decl.Name))
+		markdownContent = append(markdownContent, "")
+		if class != decl.Name {
+			markdownContent = append(markdownContent, fmt.Sprintf("**Overrides**: property of `%s`", class))
 			markdownContent = append(markdownContent, "")
 		}
-		if propertyContext.BoundProperty != "" {
-			markdownContent = append(markdownContent, fmt.Sprintf("**Bound to**: `%s`", propertyContext.BoundProperty))
-			markdownContent = append(markdownContent, "")
+	}
+	
+	if propertyType := hp.getPropertyType(inferrer, class, propertyName); propertyType != "" {
+		markdownContent = append(markdownContent, fmt.Sprintf("**Type**: `%s`", propertyType))
+		markdownContent = append(markdownContent, "")
+	}
+	
+	if decl != nil {
+		markdownContent = append(markdownContent, hp.getDeclarationInfo(decl, class, name, node, content)...)
+	}
+	
+	if hp.projectScanner.Settings().Hover.Verbosity == HoverVerbosityMinimal {
+		return &MarkupContent{
+			Kind:  MarkupKindMarkdown,
+			Value: strings.Join(markdownContent, "\n"),
 		}
 	}
 	
+	if class != "" {
+		markdownContent = append(markdownContent, hp.getInheritanceInfo(inferrer, class, name)...)
+	}
+	if decl != nil && class == decl.Name {
+		markdownContent = append(markdownContent, hp.getBindingsInfo(inferrer, decls, decl, name)...)
+	}
+	
 	// Common property descriptions
 	propertyDesc := hp.getCommonPropertyDescription(propertyName)
 	if propertyDesc != "" {
@@ -292,63 +368,206 @@ func (hp *HoverProvider) getPropertyHover(propertyName, content string) *MarkupC
 	}
 }
 
-func (hp *HoverProvider) getSubPropertyHover(propertyName, content string) *MarkupContent {
+func (hp *HoverProvider) getSubPropertyHover(propertyName, content string, position Position) *MarkupContent {
 	// For sub-properties, provide similar information as regular properties
-	return hp.getPropertyHover(propertyName, content)
+	return hp.getPropertyHover(propertyName, content, position)
 }
 
-type PropertyContext struct {
-	BindingType    string // "<=", "<=>", "=>", "^", ""
-	Value          string
-	BoundProperty  string
+// getPropertyType infers the type of a property of the class, falling back to
+// the table of well-known $mol_view properties
+func (hp *HoverProvider) getPropertyType(inferrer *TypeInferrer, class, propertyName string) string {
+	if class != "" {
+		if propertyType := inferrer.PropertyType(class, propertyName); propertyType.Kind != TypeUnknown {
+			return propertyType.String()
+		}
+	}
+	
+	if info := hp.getPropertyTypeInfo(propertyBaseName(propertyName)); info != nil {
+		return info.Type
+	}
+	return ""
 }
 
-func (hp *HoverProvider) findPropertyContext(propertyName, content string) *PropertyContext {
-	lines := strings.Split(content, "\n")
-	
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		
-		// Look for property definitions
-		if strings.Contains(line, propertyName) {
-			// Check for different binding types
-			if match := regexp.MustCompile(fmt.Sprintf(`%s\s*<=>\s*([^\\s]+)`, regexp.QuoteMeta(propertyName))).FindStringSubmatch(trimmed); len(match) > 1 {
-				return &PropertyContext{
-					BindingType:   "<=>",
-					BoundProperty: match[1],
+// getDeclarationInfo describes how the hovered property is declared in this
+// file: its source, binding kind, bound property and default value
+func (hp *HoverProvider) getDeclarationInfo(decl *ComponentDecl, class, name string, node *TreeNode, content string) []string {
+	var declNode, value *TreeNode
+	via := ""
+	if class == decl.Name {
+		property := decl.Property(name)
+		if property == nil {
+			return nil
+		}
+		declNode, value, via = property.Node, property.Value, property.Via
+	} else {
+		for _, instance := range decl.Instances {
+			for _, override := range instance.Overrides {
+				if override.Node == node {
+					declNode, value = override.Node, override.Value
 				}
 			}
-			if match := regexp.MustCompile(fmt.Sprintf(`%s\s*<=\s*([^\\s]+)`, regexp.QuoteMeta(propertyName))).FindStringSubmatch(trimmed); len(match) > 1 {
-				return &PropertyContext{
-					BindingType:   "<=",
-					BoundProperty: match[1],
-				}
+		}
+		if declNode == nil {
+			return nil
+		}
+	}
+	
+	var lines []string
+	lines = append(lines, fmt.Sprintf("**Declaration** (line %d):", declNode.Line+1))
+	lines = append(lines, "```tree")
+	lines = append(lines, nodeSource(content, declNode))
+	lines = append(lines, "```")
+	lines = append(lines, "")
+	
+	switch {
+	case value != nil && (value.Name == BindingOneWay || value.Name == BindingTwoWay || value.Name == BindingOutput):
+		if source := value.First(); source != nil {
+			lines = append(lines, fmt.Sprintf("**Binding**: `%s` `%s`", value.Name, source.Name))
+			value = source.First()
+			if property := decl.Property(source.Name); property != nil && value == nil {
+				value = property.Value
 			}
-			if match := regexp.MustCompile(fmt.Sprintf(`%s\s*=>\s*([^\\s]+)`, regexp.QuoteMeta(propertyName))).FindStringSubmatch(trimmed); len(match) > 1 {
-				return &PropertyContext{
-					BindingType:   "=>",
-					BoundProperty: match[1],
-				}
+		}
+	case value != nil && value.Name == BindingOverride:
+		lines = append(lines, "**Binding**: `^` keeps the base class value")
+		value = nil
+	case via != "":
+		lines = append(lines, fmt.Sprintf("**Binding**: introduced by `%s`", via))
+	}
+	
+	if value != nil && !strings.HasPrefix(value.Name, "<=") && value.Name != BindingOutput {
+		lines = append(lines, fmt.Sprintf("**Default**: `%s`", strings.ReplaceAll(nodeSource(content, value), "\n", " ")))
+	}
+	if len(lines) > 0 && lines[len(lines)-1] != "" {
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+// getInheritanceInfo shows the ancestor that first declares the property and
+// the TypeScript method overriding it
+func (hp *HoverProvider) getInheritanceInfo(inferrer *TypeInferrer, class, name string) []string {
+	var lines []string
+	chain := inferrer.ClassChain(class)
+	
+	// The farthest ancestor declaring the property introduced it
+	origin := ""
+	for _, ancestor := range chain {
+		if decl := inferrer.componentDecl(ancestor); decl != nil && decl.Property(name) != nil {
+			origin = ancestor
+		} else if tsClass := hp.projectScanner.GetTsClass(ancestor); tsClass != nil && tsClass.Methods[name] != nil && tsClass.Base != "$."+ancestor {
+			origin = ancestor
+		}
+	}
+	if origin != "" && origin != class {
+		location := ""
+		if decl := inferrer.componentDecl(origin); decl != nil && decl.File != "" {
+			location = fmt.Sprintf(" (`%s`)", hp.getRelativePath(decl.File))
+		}
+		lines = append(lines, fmt.Sprintf("**Declared in**: `%s`%s", origin, location))
+		lines = append(lines, "")
+	}
+	
+	for _, ancestor := range chain {
+		tsClass := hp.projectScanner.GetTsClass(ancestor)
+		if tsClass == nil || tsClass.Methods[name] == nil {
+			continue
+		}
+		method := tsClass.Methods[name]
+		signature := fmt.Sprintf("%s(%s)", method.Name, method.Params)
+		if method.ReturnType != "" {
+			signature += ": " + method.ReturnType
+		}
+		lines = append(lines, fmt.Sprintf("**TypeScript** `%s` (`%s:%d`):", tsClass.Name, hp.getRelativePath(tsClass.File), method.Range.Start.Line+1))
+		lines = append(lines, "```typescript")
+		lines = append(lines, signature)
+		lines = append(lines, "```")
+		if method.Doc != "" {
+			lines = append(lines, method.Doc)
+		}
+		lines = append(lines, "")
+		break
+	}
+	
+	return lines
+}
+
+// getBindingsInfo lists the places of the file that bind to the property:
+// the component itself and the components of the file extending it
+func (hp *HoverProvider) getBindingsInfo(inferrer *TypeInferrer, decls []*ComponentDecl, owner *ComponentDecl, name string) []string {
+	var lines []string
+	for _, decl := range decls {
+		inherits := false
+		for _, class := range inferrer.ClassChain(decl.Name) {
+			if class == owner.Name {
+				inherits = true
+				break
 			}
-			if match := regexp.MustCompile(fmt.Sprintf(`%s\s*\^\s*([^\\s]+)`, regexp.QuoteMeta(propertyName))).FindStringSubmatch(trimmed); len(match) > 1 {
-				return &PropertyContext{
-					BindingType: "^",
-					Value:       match[1],
-				}
+		}
+		if !inherits {
+			continue
+		}
+		
+		for _, binding := range decl.Bindings {
+			if binding.Source != name {
+				continue
 			}
-			// Check for direct value assignment
-			if match := regexp.MustCompile(fmt.Sprintf(`%s\s+(.+)`, regexp.QuoteMeta(propertyName))).FindStringSubmatch(trimmed); len(match) > 1 {
-				value := strings.TrimSpace(match[1])
-				if !strings.HasPrefix(value, "<=") && !strings.HasPrefix(value, "=>") && !strings.HasPrefix(value, "^") {
-					return &PropertyContext{
-						Value: value,
-					}
-				}
+			target := binding.Target
+			if target == "" {
+				target = "item"
 			}
+			if binding.Instance != nil {
+				target = binding.Instance.Component + "." + target
+			}
+			line := fmt.Sprintf("- line %d: `%s %s %s`", binding.Node.Line+1, target, binding.Kind, name)
+			if decl != owner {
+				line += fmt.Sprintf(" in `%s`", decl.Name)
+			}
+			lines = append(lines, line)
 		}
 	}
-	
-	return nil
+	if len(lines) == 0 {
+		return nil
+	}
+	lines = append([]string{"**Bound in this file**:"}, lines...)
+	return append(lines, "")
+}
+
+// nodeSourceLines is how many nested lines nodeSource shows
+const nodeSourceLines = 3
+
+// nodeSource returns the text of a node with its first nested lines, dedented
+// to the node's own line. Longer bodies end with "…".
+func nodeSource(content string, node *TreeNode) string {
+	lines := strings.Split(content, "\n")
+	last := node.Line
+	node.Walk(func(child *TreeNode) bool {
+		if child.Line > last {
+			last = child.Line
+		}
+		return true
+	})
+	if node.Line < 0 || last >= len(lines) {
+		return node.Text()
+	}
+	truncated := last > node.Line+nodeSourceLines
+	if truncated {
+		last = node.Line + nodeSourceLines
+	}
+	
+	indent := len(lines[node.Line]) - len(strings.TrimLeft(lines[node.Line], "\t"))
+	source := []string{strings.TrimRight(lines[node.Line][node.Range.Start.Character:], "\r")}
+	for _, line := range lines[node.Line+1 : last+1] {
+		line = strings.TrimRight(line, "\r")
+		if len(line) >= indent && strings.TrimLeft(line[:indent], "\t") == "" {
+			line = line[indent:]
+		}
+		source = append(source, line)
+	}
+	if truncated {
+		source = append(source, "\t…")
+	}
+	return strings.Join(source, "\n")
 }
 
 func (hp *HoverProvider) getCommonPropertyDescription(propertyName string) string {
@@ -567,7 +786,7 @@ func (hp *HoverProvider) getSpecialValueInfo(value string) *SpecialValueInfo {
 }
 
 func (hp *HoverProvider) getTypeScriptDocumentation(componentName, documentURI string) (string, error) {
-	filePath := hp.uriToFilePath(documentURI)
+	filePath := uriToPath(documentURI)
 	tsPath := strings.Replace(filePath, ".view.tree", ".ts", 1)
 	
 	content, err := os.ReadFile(tsPath)
@@ -644,12 +863,4 @@ func (hp *HoverProvider) getTextInRange(content string, r Range) string {
 	}
 	
 	return line[r.Start.Character:r.End.Character]
-}
-
-func (hp *HoverProvider) uriToFilePath(uri string) string {
-	// Simple URI to file path conversion
-	if strings.HasPrefix(uri, "file://") {
-		return strings.TrimPrefix(uri, "file://")
-	}
-	return uri
 }
\ No newline at end of file
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ignoreFileNames are read in every scanned directory, later files win
+var ignoreFileNames = []string{".gitignore", ".ignore"}
+
+var metaPackRegex = regexp.MustCompile(`^pack\s+(\S+)\s+git\b`)
+
+// ignoreRule is a single .gitignore pattern, scoped to the directory of its file
+type ignoreRule struct {
+	regex    *regexp.Regexp
+	negate   bool
+	dirOnly  bool
+	anchored bool // pattern contains a slash and matches relative to its directory
+}
+
+func (r ignoreRule) matches(relPath string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.anchored {
+		return r.regex.MatchString(relPath)
+	}
+	return r.regex.MatchString(path.Base(relPath))
+}
+
+// ignoreDir holds the rules and MAM packs declared in one directory
+type ignoreDir struct {
+	rules []ignoreRule
+	packs map[string]bool
+}
+
+// ignoreMatcher decides which paths under a scan root are skipped. It honors
+// .gitignore and .ignore files at every level and keeps directories declared
+// with "pack" in *.meta.tree files, which are separate repositories that the
+// enclosing .gitignore usually lists. Other meta.tree rules such as "deploy"
+// are not read.
+type ignoreMatcher struct {
+	root  string
+	dirs  map[string]*ignoreDir // keyed by slash-separated path relative to root
+	mutex sync.Mutex
+}
+
+func newIgnoreMatcher(root string) *ignoreMatcher {
+	return &ignoreMatcher{
+		root: root,
+		dirs: make(map[string]*ignoreDir),
+	}
+}
+
+// Ignored reports whether a slash-separated path relative to the root is
+// skipped, assuming its parent directories are not
+func (m *ignoreMatcher) Ignored(relPath string, isDir bool) bool {
+	if relPath == "." || relPath == "" {
+		return false
+	}
+
+	segments := strings.Split(relPath, "/")
+	name := segments[len(segments)-1]
+	parent := strings.Join(segments[:len(segments)-1], "/")
+
+	if isDir && m.dir(parent).packs[name] {
+		return false
+	}
+
+	// Rules of deeper directories override those of their parents
+	ignored := false
+	for i := 0; i < len(segments); i++ {
+		dir := strings.Join(segments[:i], "/")
+		subPath := strings.Join(segments[i:], "/")
+		for _, rule := range m.dir(dir).rules {
+			if rule.matches(subPath, isDir) {
+				ignored = !rule.negate
+			}
+		}
+	}
+
+	return ignored
+}
+
+// IgnoredFile reports whether a file, or any of its parent directories, is skipped
+func (m *ignoreMatcher) IgnoredFile(relPath string) bool {
+	segments := strings.Split(relPath, "/")
+	for i := 1; i < len(segments); i++ {
+		if m.Ignored(strings.Join(segments[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.Ignored(relPath, false)
+}
+
+// dir loads the ignore files and meta.tree packs of a directory once
+func (m *ignoreMatcher) dir(relDir string) *ignoreDir {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if loaded, exists := m.dirs[relDir]; exists {
+		return loaded
+	}
+
+	absDir := filepath.Join(m.root, filepath.FromSlash(relDir))
+	loaded := &ignoreDir{packs: make(map[string]bool)}
+
+	for _, name := range ignoreFileNames {
+		loaded.rules = append(loaded.rules, readIgnoreFile(filepath.Join(absDir, name))...)
+	}
+
+	metaFiles, _ := filepath.Glob(filepath.Join(absDir, "*.meta.tree"))
+	for _, metaFile := range metaFiles {
+		for _, pack := range readMetaPacks(metaFile) {
+			loaded.packs[pack] = true
+		}
+	}
+
+	m.dirs[relDir] = loaded
+	return loaded
+}
+
+// isBuildOutputDir reports whether a directory holds MAM build output such as
+// "-", "-view.tree" or "-css"
+func isBuildOutputDir(name string) bool {
+	return strings.HasPrefix(name, "-")
+}
+
+// readIgnoreFile parses a .gitignore style file. Missing files yield no rules.
+func readIgnoreFile(filePath string) []ignoreRule {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreLine(scanner.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func parseIgnoreLine(line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		// Escaped leading "#" or "!"
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	rule.anchored = strings.Contains(line, "/")
+	rule.regex = compileGlob(strings.TrimPrefix(line, "/"))
+	return rule, true
+}
+
+// isIgnoreRulesFile reports whether a file feeds the ignore matcher
+func isIgnoreRulesFile(filePath string) bool {
+	name := filepath.Base(filePath)
+	for _, ignoreFile := range ignoreFileNames {
+		if name == ignoreFile {
+			return true
+		}
+	}
+	return strings.HasSuffix(name, ".meta.tree")
+}
+
+// readMetaPacks returns the directory names declared as "pack <name> git \<url>"
+func readMetaPacks(filePath string) []string {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil
+	}
+
+	var packs []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if match := metaPackRegex.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			packs = append(packs, match[1])
+		}
+	}
+	return packs
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"strings"
+)
+
+// InlayHintProvider annotates view.tree documents with what the reader
+// would otherwise look up: the type a bare binding resolves to, the ancestor
+// a "^" keeps the value of, the key of a "@" string and inherited defaults
+type InlayHintProvider struct {
+	projectScanner *ProjectScanner
+	typeInferrer   *TypeInferrer
+}
+
+func NewInlayHintProvider(projectScanner *ProjectScanner) *InlayHintProvider {
+	return &InlayHintProvider{
+		projectScanner: projectScanner,
+		typeInferrer:   NewTypeInferrer(projectScanner),
+	}
+}
+
+// ProvideInlayHints returns the hints on the lines of the range, as enabled
+// in the settings
+func (ip *InlayHintProvider) ProvideInlayHints(document *TextDocument, hintRange Range) []InlayHint {
+	hints := []InlayHint{}
+	settings := ip.projectScanner.Settings().InlayHints
+	decls := BuildComponentDecls(ParseTree(document.Text), uriToPath(document.URI))
+	inferrer := ip.typeInferrer.ForDocument(decls)
+
+	add := func(position Position, label string, kind InlayHintKind) {
+		if position.Line < hintRange.Start.Line || position.Line > hintRange.End.Line {
+			return
+		}
+		hints = append(hints, InlayHint{Position: position, Label: label, Kind: kind, PaddingLeft: kind != InlayHintKindType})
+	}
+
+	for _, decl := range decls {
+		for _, binding := range decl.Bindings {
+			// Only bare bindings: "<= title \Hello" already shows its value
+			if binding.SourceNode == nil || len(binding.SourceNode.Children) > 0 {
+				continue
+			}
+			end := binding.SourceNode.Range.End
+			if settings.Types {
+				valueType := inferrer.PropertyType(decl.Name, binding.Source)
+				if !valueType.IsKnown() && decl.Base != "" {
+					// A bare binding of an inherited property has the inherited type
+					valueType = inferrer.PropertyType(decl.Base, binding.Source)
+				}
+				if valueType.IsKnown() {
+					add(end, ": "+valueType.String(), InlayHintKindType)
+				}
+			}
+			if settings.Defaults {
+				if property := decl.Property(binding.Source); property != nil && property.Value == nil {
+					if _, value := ip.inheritedValue(inferrer, inferrer.ClassChain(decl.Base), binding.Source); value != nil {
+						add(end, "= "+valueSummary(value), InlayHintKindParameter)
+					}
+				}
+			}
+		}
+
+		decl.Node.Walk(func(node *TreeNode) bool {
+			if node.Name != BindingOverride || node.IsData() {
+				return true
+			}
+			property, chain := ip.overriddenProperty(inferrer, decl, node)
+			if property == nil {
+				return true
+			}
+			origin, value := ip.inheritedValue(inferrer, chain, propertyBaseName(property.Name))
+			if origin == "" {
+				return true
+			}
+			if settings.Overrides {
+				add(node.Range.End, "from "+origin, InlayHintKindParameter)
+			}
+			if settings.Defaults && value != nil && value.Name != BindingOverride {
+				add(node.Range.End, "= "+valueSummary(value), InlayHintKindParameter)
+			}
+			return true
+		})
+	}
+
+	if settings.LocaleKeys {
+		for _, localized := range LocaleStrings(decls) {
+			add(localized.Range().End, localized.Key, InlayHintKindParameter)
+		}
+	}
+
+	return hints
+}
+
+// overriddenProperty returns the property node a "^" belongs to, skipping
+// list and dictionary markers, with the classes to look its value up in:
+// the base class, or the component of a child instance
+func (ip *InlayHintProvider) overriddenProperty(inferrer *TypeInferrer, decl *ComponentDecl, node *TreeNode) (*TreeNode, []string) {
+	property := node.Parent
+	for property != nil && (strings.HasPrefix(property.Name, "/") || strings.HasPrefix(property.Name, "*")) {
+		property = property.Parent
+	}
+	if property == nil || property == decl.Node || property == decl.BaseNode || !isPropertyName(property.Name) {
+		return nil, nil
+	}
+
+	if owner := property.Parent; owner != nil && owner != decl.Node && owner != decl.BaseNode && strings.HasPrefix(owner.Name, "$") {
+		return property, inferrer.ClassChain(owner.Name)
+	}
+	return property, inferrer.ClassChain(decl.Base)
+}
+
+// inheritedValue returns the first class of the chain declaring the
+// property, with its default value
+func (ip *InlayHintProvider) inheritedValue(inferrer *TypeInferrer, chain []string, name string) (string, *TreeNode) {
+	for _, class := range chain {
+		decl := inferrer.componentDecl(class)
+		if decl == nil {
+			continue
+		}
+		if property := decl.Property(name); property != nil {
+			return class, property.Value
+		}
+	}
+	return "", nil
+}
+
+// valueSummary renders a default value on one line
+func valueSummary(value *TreeNode) string {
+	summary := value.Text()
+	if first := value.First(); first != nil && value.Name == "@" {
+		summary += " " + first.Text()
+	} else if len(value.Children) > 0 {
+		summary += " …"
+	}
+	return summary
+}
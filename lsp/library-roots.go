@@ -0,0 +1,169 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var libraryClassRegex = regexp.MustCompile(`class\s+(\$\w+)`)
+
+// libraryRoots resolves the configured library roots, expanding globs such as
+// "node_modules/mol_*", and adds auto-detected roots when enabled
+func (ps *ProjectScanner) libraryRoots() []string {
+	settings := ps.Settings()
+	seen := make(map[string]bool)
+	var roots []string
+
+	add := func(root string) {
+		root = filepath.Clean(root)
+		if seen[root] || root == filepath.Clean(ps.workspaceRoot) {
+			return
+		}
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return
+		}
+		seen[root] = true
+		roots = append(roots, root)
+	}
+
+	for _, pattern := range settings.LibraryRoots {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(ps.workspaceRoot, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			log.Printf("[view.tree] Invalid library root %s: %v", pattern, err)
+			continue
+		}
+		for _, match := range matches {
+			add(match)
+		}
+	}
+
+	if settings.DetectLibraries {
+		for _, root := range detectLibraryRoots(ps.workspaceRoot) {
+			add(root)
+		}
+	}
+
+	return roots
+}
+
+// detectLibraryRoots finds the $mol sources of an enclosing MAM root and
+// mol_* packages installed into node_modules
+func detectLibraryRoots(workspaceRoot string) []string {
+	var roots []string
+
+	absRoot, err := filepath.Abs(workspaceRoot)
+	if err != nil {
+		return roots
+	}
+
+	// MAM root: the closest ancestor containing mol/view
+	for dir := filepath.Dir(absRoot); ; dir = filepath.Dir(dir) {
+		molRoot := filepath.Join(dir, "mol")
+		if info, err := os.Stat(filepath.Join(molRoot, "view")); err == nil && info.IsDir() {
+			if !strings.HasPrefix(absRoot+string(filepath.Separator), molRoot+string(filepath.Separator)) {
+				roots = append(roots, molRoot)
+			}
+			break
+		}
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+
+	packages, err := filepath.Glob(filepath.Join(absRoot, "node_modules", "mol_*"))
+	if err == nil {
+		sort.Strings(packages)
+		roots = append(roots, packages...)
+	}
+
+	return roots
+}
+
+// scanLibraries builds the read-only library index and returns it with the
+// library roots. Library sources are .view.tree files plus class declarations
+// from .ts and .d.ts files.
+func (ps *ProjectScanner) scanLibraries(matchers map[string]*ignoreMatcher) (*ProjectData, []string) {
+	roots := ps.libraryRoots()
+	libraryData := NewProjectData()
+
+	if len(roots) > 0 {
+		files, err := ps.walkFiles(roots, matchers, func(path string) bool {
+			return strings.HasSuffix(path, ".view.tree") || strings.HasSuffix(path, ".ts")
+		}, ps.Settings().MaxLibraryFiles)
+		if err != nil {
+			log.Printf("[view.tree] Error scanning library roots: %v", err)
+		}
+
+		for _, filePath := range files {
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				log.Printf("[view.tree] Error reading %s: %v", filePath, err)
+				continue
+			}
+
+			if strings.HasSuffix(filePath, ".view.tree") {
+				ps.parseViewTreeInto(libraryData, string(content), filePath)
+			} else {
+				ps.parseLibraryTsInto(libraryData, string(content), filePath)
+			}
+		}
+
+		log.Printf("[view.tree] Indexed %d library files from %s", len(files), strings.Join(roots, ", "))
+	}
+
+	return libraryData, roots
+}
+
+// parseLibraryTsInto registers classes declared in a library TypeScript file.
+// Unlike workspace files, mere mentions of $ names are not indexed.
+func (ps *ProjectScanner) parseLibraryTsInto(data *ProjectData, content, filePath string) {
+	matches := libraryClassRegex.FindAllStringSubmatch(content, -1)
+	if len(matches) == 0 {
+		return
+	}
+	classes := ParseTsClasses(content, filePath)
+
+	data.mutex.Lock()
+	defer data.mutex.Unlock()
+
+	if data.FileComponents[filePath] == nil {
+		data.FileComponents[filePath] = make(map[string]bool)
+	}
+
+	// Declaration files carry the return types, so they win over sources
+	for _, class := range classes {
+		if existing := data.TsClasses[class.Name]; existing == nil || strings.HasSuffix(filePath, ".d.ts") {
+			data.TsClasses[class.Name] = class
+		}
+	}
+
+	for _, match := range matches {
+		component := match[1]
+		data.Components[component] = true
+		// .view.tree declarations take precedence over TypeScript
+		if _, exists := data.ComponentFiles[component]; !exists {
+			data.ComponentFiles[component] = filePath
+		}
+		data.FileComponents[filePath][component] = true
+	}
+}
+
+// isLibraryPath reports whether a file lies under one of the library roots
+func (ps *ProjectScanner) isLibraryPath(filePath string) bool {
+	ps.stateMutex.RLock()
+	defer ps.stateMutex.RUnlock()
+
+	for _, root := range ps.libraryPaths {
+		if strings.HasPrefix(filePath, root+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
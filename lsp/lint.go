@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// runLint implements "lsp-view-tree lint --unused [root]". It prints the dead
+// components of the workspace and returns the process exit code.
+func runLint(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	unused := flags.Bool("unused", false, "report components nothing extends, instantiates or references")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if !*unused {
+		fmt.Fprintln(stderr, "usage: lsp-view-tree lint --unused [root]")
+		return 2
+	}
+
+	root := "."
+	if flags.NArg() > 0 {
+		root = flags.Arg(0)
+	}
+
+	settings, err := LoadSettingsFile(root)
+	if err != nil {
+		fmt.Fprintf(stderr, "lint: %v\n", err)
+		return 2
+	}
+
+	scanner := NewProjectScanner(root)
+	scanner.SetSettings(settings)
+	if err := scanner.ScanProject(); err != nil {
+		fmt.Fprintf(stderr, "lint: %v\n", err)
+		return 2
+	}
+
+	dead := NewUsageAnalyzer(scanner).DeadComponents()
+	files := make([]string, 0, len(dead))
+	for filePath := range dead {
+		files = append(files, filePath)
+	}
+	sort.Strings(files)
+
+	count := 0
+	for _, filePath := range files {
+		relPath, err := filepath.Rel(root, filePath)
+		if err != nil {
+			relPath = filePath
+		}
+		for _, decl := range dead[filePath] {
+			start := decl.Node.Range.Start
+			fmt.Fprintf(stdout, "%s:%d:%d: unused component %s\n", relPath, start.Line+1, start.Character+1, decl.Name)
+			count++
+		}
+	}
+
+	if count > 0 {
+		fmt.Fprintf(stdout, "%d unused component(s)\n", count)
+		return 1
+	}
+	return 0
+}
+
+// lintMain runs the lint command with quiet scanner logging
+func lintMain(args []string) {
+	log.SetOutput(io.Discard)
+	os.Exit(runLint(args, os.Stdout, os.Stderr))
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// localeFileRegex matches MAM translation files such as "app.locale=ru.json"
+var localeFileRegex = regexp.MustCompile(`\.locale=([\w-]+)\.json$`)
+
+// LocaleString is a "@ \text" localized string of a view.tree component
+type LocaleString struct {
+	Key  string    // e.g. "$my_app_Selector_empty"
+	Text string    // source text, used as the default language
+	Node *TreeNode // "@" node
+	Data *TreeNode // text node, nil for "@" without a text
+}
+
+// LocaleFile is a *.locale=<lang>.json file next to a view.tree file. Loaded
+// files are cached and shared, callers must not modify them.
+type LocaleFile struct {
+	Language string
+	File     string
+	Content  string
+	Entries  map[string]string
+	modTime  time.Time
+	size     int64
+}
+
+// localeFileCache keeps parsed locale files by path until they change on disk
+var localeFileCache = struct {
+	files map[string]*LocaleFile
+	mutex sync.Mutex
+}{files: make(map[string]*LocaleFile)}
+
+// LocaleStrings collects the localized strings of the declarations
+func LocaleStrings(decls []*ComponentDecl) []*LocaleString {
+	var result []*LocaleString
+	for _, decl := range decls {
+		decl.Node.Walk(func(node *TreeNode) bool {
+			if node.Name != "@" || node.IsData() {
+				return true
+			}
+			localized := &LocaleString{
+				Key:  LocaleKey(decl, node),
+				Node: node,
+			}
+			if data := node.First(); data != nil && data.IsData() {
+				localized.Text = data.Data
+				localized.Data = data
+			}
+			result = append(result, localized)
+			return true
+		})
+	}
+	return result
+}
+
+// LocaleStringAt returns the localized string whose "@" or text is at the position
+func LocaleStringAt(decls []*ComponentDecl, position Position) *LocaleString {
+	for _, localized := range LocaleStrings(decls) {
+		if rangeContains(localized.Node.Range, position) ||
+			(localized.Data != nil && rangeContains(localized.Data.Range, position)) {
+			return localized
+		}
+	}
+	return nil
+}
+
+// LocaleKey builds the key $mol generates for a "@" value: the component name
+// followed by the property path through child instances, e.g. "Selector_empty"
+// for "empty @ \..." inside "<= Selector $mol_select"
+func LocaleKey(decl *ComponentDecl, value *TreeNode) string {
+	var path []string
+	for node := value.Parent; node != nil && node != decl.Node && node != decl.BaseNode; {
+		path = append([]string{propertyBaseName(node.Name)}, path...)
+
+		// Overrides of a child instance continue with the property creating it
+		instance := node.Parent
+		if instance == nil || instance == decl.Node || instance == decl.BaseNode ||
+			!strings.HasPrefix(instance.Name, "$") || instance.Parent == nil {
+			break
+		}
+		node = instance.Parent
+	}
+	return decl.Name + "_" + strings.Join(path, "_")
+}
+
+// LocaleFilesFor reads the translation files in the directory of a view.tree
+// file, sorted by language
+func LocaleFilesFor(viewTreeFile string) []*LocaleFile {
+	if viewTreeFile == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(filepath.Dir(viewTreeFile))
+	if err != nil {
+		return nil
+	}
+
+	var files []*LocaleFile
+	for _, entry := range entries {
+		match := localeFileRegex.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if file := loadLocaleFile(filepath.Join(filepath.Dir(viewTreeFile), entry.Name()), match[1], info); file != nil {
+			files = append(files, file)
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Language < files[j].Language
+	})
+	return files
+}
+
+// loadLocaleFile returns the parsed locale file, reading it again only when
+// its modification time or size changed
+func loadLocaleFile(filePath, language string, info os.FileInfo) *LocaleFile {
+	localeFileCache.mutex.Lock()
+	defer localeFileCache.mutex.Unlock()
+
+	if cached := localeFileCache.files[filePath]; cached != nil && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		delete(localeFileCache.files, filePath)
+		return nil
+	}
+	file := &LocaleFile{
+		Language: language,
+		File:     filePath,
+		Content:  string(content),
+		Entries:  make(map[string]string),
+		modTime:  info.ModTime(),
+		size:     info.Size(),
+	}
+	// A broken file still takes part, as if it had no entries
+	_ = json.Unmarshal(content, &file.Entries)
+	localeFileCache.files[filePath] = file
+	return file
+}
+
+// KeyRange returns the range of the quoted key in the file
+func (lf *LocaleFile) KeyRange(key string) (Range, bool) {
+	quoted := `"` + key + `"`
+	for lineIndex, line := range strings.Split(lf.Content, "\n") {
+		if index := strings.Index(line, quoted); index >= 0 {
+			return Range{
+				Start: Position{Line: lineIndex, Character: index},
+				End:   Position{Line: lineIndex, Character: index + len(quoted)},
+			}, true
+		}
+	}
+	return Range{}, false
+}
+
+// StubEdit returns the edit adding a "key": "text" entry after the last one,
+// keeping the indentation of the existing entries
+func (lf *LocaleFile) StubEdit(key, text string) (TextEdit, bool) {
+	closing := strings.LastIndex(lf.Content, "}")
+	if closing < 0 {
+		return TextEdit{}, false
+	}
+	last := len(strings.TrimRight(lf.Content[:closing], " \t\r\n"))
+	if last == 0 {
+		return TextEdit{}, false
+	}
+
+	indent := "\t"
+	for _, line := range strings.Split(lf.Content, "\n") {
+		if trimmed := strings.TrimLeft(line, " \t"); strings.HasPrefix(trimmed, `"`) && len(trimmed) < len(line) {
+			indent = line[:len(line)-len(trimmed)]
+			break
+		}
+	}
+
+	entry := indent + jsonString(key) + ": " + jsonString(text)
+	if lf.Content[last-1] == '{' {
+		// Empty object: put the entry between the braces
+		return TextEdit{
+			Range:   Range{Start: offsetPosition(lf.Content, last), End: offsetPosition(lf.Content, closing)},
+			NewText: "\n" + entry + "\n",
+		}, true
+	}
+	return TextEdit{
+		Range:   Range{Start: offsetPosition(lf.Content, last), End: offsetPosition(lf.Content, last)},
+		NewText: ",\n" + entry,
+	}, true
+}
+
+// offsetPosition converts a byte offset into a position
+func offsetPosition(content string, offset int) Position {
+	lineStart := strings.LastIndex(content[:offset], "\n") + 1
+	return Position{Line: strings.Count(content[:offset], "\n"), Character: offset - lineStart}
+}
+
+// jsonString encodes a string for JSON without escaping HTML characters
+func jsonString(value string) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return `""`
+	}
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
+
+// Range spans the "@" and its text
+func (ls *LocaleString) Range() Range {
+	if ls.Data == nil {
+		return ls.Node.Range
+	}
+	return Range{Start: ls.Node.Range.Start, End: ls.Data.Range.End}
+}
+
+// MissingIn returns the locale files without a translation of the string
+func (ls *LocaleString) MissingIn(files []*LocaleFile) []*LocaleFile {
+	var missing []*LocaleFile
+	for _, file := range files {
+		if _, ok := file.Entries[ls.Key]; !ok {
+			missing = append(missing, file)
+		}
+	}
+	return missing
+}
+
+// localeLanguages joins the languages of the files: "de, ru"
+func localeLanguages(files []*LocaleFile) string {
+	languages := make([]string, len(files))
+	for i, file := range files {
+		languages[i] = file.Language
+	}
+	return strings.Join(languages, ", ")
+}
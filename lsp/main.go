@@ -6,6 +6,11 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "lint" {
+		lintMain(os.Args[2:])
+		return
+	}
+	
 	// Set up logging to stderr (LSP uses stdin/stdout for communication)
 	log.SetOutput(os.Stderr)
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
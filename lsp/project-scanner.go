@@ -17,7 +17,15 @@ type ProjectData struct {
 	ComponentProperties map[string]map[string]bool // Map of component -> properties
 	ComponentFiles      map[string]string          // Map of component -> file path
 	FileComponents      map[string]map[string]bool // Map of file path -> components
+	Declarations        map[string]*ComponentDecl  // Map of component -> view.tree declaration
+	TsClasses           map[string]*TsClass        // Map of class name -> TypeScript class
 	mutex               sync.RWMutex
+
+	// Namespaces declared by .view.tree files, rebuilt on first use after a
+	// .view.tree file changed. Peer roots are looked up by namespace for
+	// every component, so this must not walk ComponentFiles each time.
+	namespaces     map[string]bool
+	namespaceMutex sync.Mutex
 }
 
 func NewProjectData() *ProjectData {
@@ -26,50 +34,114 @@ func NewProjectData() *ProjectData {
 		ComponentProperties: make(map[string]map[string]bool),
 		ComponentFiles:      make(map[string]string),
 		FileComponents:      make(map[string]map[string]bool),
+		Declarations:        make(map[string]*ComponentDecl),
+		TsClasses:           make(map[string]*TsClass),
 	}
 }
 
 type ProjectScanner struct {
 	workspaceRoot string
-	projectData   *ProjectData
+	
+	// Index of the workspace sources, replaced as a whole by full scans
+	projectData *ProjectData
+	
+	// Read-only index of library roots, consulted after workspace sources
+	libraryData  *ProjectData
+	libraryPaths []string
+	
+	settings *Settings
+	
+	// stateMutex guards settings, peers, the indexes and ignore rules
+	stateMutex sync.RWMutex
+	
+	// peers returns the scanners of all workspace roots, including this one
+	peers func() []*ProjectScanner
+	
+	// ignore rules per scan root, reloaded on every full scan
+	ignoreMatchers map[string]*ignoreMatcher
+	
+	// Index version for caches derived from the index: revision counts full
+	// scans and single file updates, revisionFile is the file of the last
+	// update and empty after a full scan
+	revision     uint64
+	revisionFile string
 }
 
 func NewProjectScanner(workspaceRoot string) *ProjectScanner {
 	return &ProjectScanner{
 		workspaceRoot: workspaceRoot,
 		projectData:   NewProjectData(),
+		libraryData:   NewProjectData(),
+		settings:      DefaultSettings(),
+		ignoreMatchers: make(map[string]*ignoreMatcher),
 	}
 }
 
+// Settings returns the current workspace settings
+func (ps *ProjectScanner) Settings() *Settings {
+	ps.stateMutex.RLock()
+	defer ps.stateMutex.RUnlock()
+	
+	return ps.settings
+}
+
+// SetPeers links the scanner with the other workspace roots. Components that are
+// not found locally resolve against peers that declare the same $ namespace.
+func (ps *ProjectScanner) SetPeers(peers func() []*ProjectScanner) {
+	ps.stateMutex.Lock()
+	defer ps.stateMutex.Unlock()
+	
+	ps.peers = peers
+}
+
+// SetSettings replaces the workspace settings. It does not rescan the project.
+func (ps *ProjectScanner) SetSettings(settings *Settings) {
+	ps.stateMutex.Lock()
+	defer ps.stateMutex.Unlock()
+	
+	ps.settings = settings
+}
+
 func (ps *ProjectScanner) ScanProject() error {
 	log.Println("[view.tree] Starting project scan...")
 	
-	// Reset project data
-	ps.projectData = NewProjectData()
+	// The new index is built aside, readers keep the previous one until the
+	// scan is complete
+	data := NewProjectData()
+	matchers := make(map[string]*ignoreMatcher)
 	
 	// Scan .view.tree files
-	if err := ps.scanViewTreeFiles(); err != nil {
+	if err := ps.scanViewTreeFiles(data, matchers); err != nil {
 		log.Printf("[view.tree] Error scanning view.tree files: %v", err)
 	}
 	
 	// Scan .ts files
-	if err := ps.scanTsFiles(); err != nil {
+	if err := ps.scanTsFiles(data, matchers); err != nil {
 		log.Printf("[view.tree] Error scanning ts files: %v", err)
 	}
 	
-	ps.projectData.mutex.RLock()
-	componentCount := len(ps.projectData.Components)
-	propertiesCount := len(ps.projectData.ComponentProperties)
-	ps.projectData.mutex.RUnlock()
-	
-	log.Printf("[view.tree] Scan complete: %d components, %d components with properties", componentCount, propertiesCount)
-	
+	// Scan library roots into the read-only index
+	libraryData, libraryPaths := ps.scanLibraries(matchers)
+	
+	ps.stateMutex.Lock()
+	ps.projectData = data
+	ps.libraryData = libraryData
+	ps.libraryPaths = libraryPaths
+	ps.ignoreMatchers = matchers
+	ps.revision++
+	ps.revisionFile = ""
+	ps.stateMutex.Unlock()
+	
+	data.mutex.RLock()
+	componentCount := len(data.Components)
+	propertiesCount := len(data.ComponentProperties)
 	var componentNames []string
-	ps.projectData.mutex.RLock()
-	for component := range ps.projectData.Components {
+	for component := range data.Components {
 		componentNames = append(componentNames, component)
 	}
-	ps.projectData.mutex.RUnlock()
+	data.mutex.RUnlock()
+	
+	log.Printf("[view.tree] Scan complete: %d components, %d components with properties", componentCount, propertiesCount)
 	
 	if len(componentNames) > 0 {
 		sort.Strings(componentNames)
@@ -79,8 +151,8 @@ func (ps *ProjectScanner) ScanProject() error {
 	return nil
 }
 
-func (ps *ProjectScanner) scanViewTreeFiles() error {
-	viewTreeFiles, err := ps.findFiles("**/*.view.tree")
+func (ps *ProjectScanner) scanViewTreeFiles(data *ProjectData, matchers map[string]*ignoreMatcher) error {
+	viewTreeFiles, err := ps.findFiles("**/*.view.tree", matchers)
 	if err != nil {
 		return fmt.Errorf("failed to find view.tree files: %w", err)
 	}
@@ -94,23 +166,24 @@ func (ps *ProjectScanner) scanViewTreeFiles() error {
 			continue
 		}
 		
-		ps.parseViewTreeFile(string(content), filePath)
+		ps.parseViewTreeInto(data, string(content), filePath)
 	}
 	
 	return nil
 }
 
-func (ps *ProjectScanner) scanTsFiles() error {
-	tsFiles, err := ps.findFiles("**/*.ts")
+func (ps *ProjectScanner) scanTsFiles(data *ProjectData, matchers map[string]*ignoreMatcher) error {
+	tsFiles, err := ps.findFiles("**/*.ts", matchers)
 	if err != nil {
 		return fmt.Errorf("failed to find ts files: %w", err)
 	}
 	
 	log.Printf("[view.tree] Found %d .ts files", len(tsFiles))
 	
-	// Limit to first 100 files for performance
-	if len(tsFiles) > 100 {
-		tsFiles = tsFiles[:100]
+	// Limit the number of files for performance
+	maxTsFiles := ps.Settings().MaxTsFiles
+	if maxTsFiles > 0 && len(tsFiles) > maxTsFiles {
+		tsFiles = tsFiles[:maxTsFiles]
 	}
 	
 	for _, filePath := range tsFiles {
@@ -120,58 +193,209 @@ func (ps *ProjectScanner) scanTsFiles() error {
 			continue
 		}
 		
-		ps.parseTsFile(string(content), filePath)
+		ps.parseTsInto(data, string(content), filePath)
 	}
 	
 	return nil
 }
 
-func (ps *ProjectScanner) findFiles(pattern string) ([]string, error) {
-	var files []string
-	
-	err := filepath.WalkDir(ps.workspaceRoot, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return nil // Skip errors and continue
+// scanRoots returns the workspace root followed by the configured extra scan roots
+func (ps *ProjectScanner) scanRoots() []string {
+	roots := []string{ps.workspaceRoot}
+	for _, root := range ps.Settings().ScanRoots {
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(ps.workspaceRoot, root)
 		}
-		
-		if d.IsDir() {
-			// Skip hidden directories and node_modules
-			if strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" {
-				return filepath.SkipDir
+		roots = append(roots, root)
+	}
+	return roots
+}
+
+func (ps *ProjectScanner) findFiles(pattern string, matchers map[string]*ignoreMatcher) ([]string, error) {
+	return ps.walkFiles(ps.scanRoots(), matchers, func(path string) bool {
+		if strings.Contains(pattern, "*.view.tree") && strings.HasSuffix(path, ".view.tree") {
+			return true
+		}
+		return strings.Contains(pattern, "*.ts") && strings.HasSuffix(path, ".ts") && !strings.HasSuffix(path, ".d.ts")
+	}, 0)
+}
+
+// walkFiles collects files accepted by include under the given roots,
+// skipping hidden directories, nested node_modules, ignored and excluded paths.
+// Ignore rules are loaded into matchers, which belongs to the calling scan.
+// The walk stops once limit files are collected, a limit of 0 or less means
+// no limit.
+func (ps *ProjectScanner) walkFiles(roots []string, matchers map[string]*ignoreMatcher, include func(path string) bool, limit int) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	settings := ps.Settings()
+	exclude := settings.Exclude
+	
+	for _, root := range roots {
+		matcher := loadIgnoreMatcher(matchers, settings, root)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // Skip errors and continue
 			}
+			
+			if ps.isExcluded(root, path, exclude) || ps.isIgnored(matcher, root, path, d.IsDir()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			
+			if d.IsDir() {
+				// Skip hidden directories, node_modules and build outputs
+				if path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" || isBuildOutputDir(d.Name())) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			
+			if !seen[path] && include(path) {
+				files = append(files, path)
+				seen[path] = true
+				if limit > 0 && len(files) >= limit {
+					return filepath.SkipAll
+				}
+			}
+			
 			return nil
+		})
+		if err != nil {
+			return files, err
 		}
-		
-		if strings.Contains(pattern, "*.view.tree") && strings.HasSuffix(path, ".view.tree") {
-			files = append(files, path)
-		} else if strings.Contains(pattern, "*.ts") && strings.HasSuffix(path, ".ts") && !strings.HasSuffix(path, ".d.ts") {
-			files = append(files, path)
+		if limit > 0 && len(files) >= limit {
+			log.Printf("[view.tree] Stopped scanning after %d files", limit)
+			break
 		}
-		
+	}
+	
+	return files, nil
+}
+
+// isExcluded checks a path against the user exclude globs
+func (ps *ProjectScanner) isExcluded(root, path string, exclude []string) bool {
+	relPath, err := filepath.Rel(root, path)
+	if err != nil || relPath == "." {
+		return false
+	}
+	
+	for _, pattern := range exclude {
+		if matchGlob(pattern, relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// isIgnored checks a path against .gitignore, .ignore and MAM rules
+func (ps *ProjectScanner) isIgnored(matcher *ignoreMatcher, root, path string, isDir bool) bool {
+	if matcher == nil {
+		return false
+	}
+	
+	relPath, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return matcher.Ignored(filepath.ToSlash(relPath), isDir)
+}
+
+// ignoreMatcher returns the cached ignore rules of a scan root, or nil
+// when ignore files are disabled
+func (ps *ProjectScanner) ignoreMatcher(root string) *ignoreMatcher {
+	ps.stateMutex.Lock()
+	defer ps.stateMutex.Unlock()
+	
+	return loadIgnoreMatcher(ps.ignoreMatchers, ps.settings, root)
+}
+
+// loadIgnoreMatcher returns the ignore rules of a root from matchers,
+// reading them on first use
+func loadIgnoreMatcher(matchers map[string]*ignoreMatcher, settings *Settings, root string) *ignoreMatcher {
+	if !settings.RespectIgnoreFiles {
 		return nil
-	})
+	}
 	
-	return files, err
+	matcher, exists := matchers[root]
+	if !exists {
+		matcher = newIgnoreMatcher(root)
+		matchers[root] = matcher
+	}
+	return matcher
+}
+
+func (ps *ProjectScanner) resetIgnoreMatchers() {
+	ps.stateMutex.Lock()
+	defer ps.stateMutex.Unlock()
+	
+	ps.ignoreMatchers = make(map[string]*ignoreMatcher)
+}
+
+// isSkippedFile reports whether a file inside a scan root would not be indexed
+// by a full scan because it is excluded or ignored
+func (ps *ProjectScanner) isSkippedFile(filePath string) bool {
+	exclude := ps.Settings().Exclude
+	
+	for _, root := range ps.scanRoots() {
+		relPath, err := filepath.Rel(root, filePath)
+		if err != nil || strings.HasPrefix(relPath, "..") {
+			continue
+		}
+		
+		if ps.isExcluded(root, filePath, exclude) {
+			return true
+		}
+		for _, dir := range strings.Split(filepath.Dir(relPath), string(filepath.Separator)) {
+			if isBuildOutputDir(dir) {
+				return true
+			}
+		}
+		if matcher := ps.ignoreMatcher(root); matcher != nil && matcher.IgnoredFile(filepath.ToSlash(relPath)) {
+			return true
+		}
+		return false
+	}
+	return false
 }
 
 func (ps *ProjectScanner) parseViewTreeFile(content, filePath string) {
+	ps.parseViewTreeInto(ps.project(), content, filePath)
+}
+
+func (ps *ProjectScanner) parseViewTreeInto(data *ProjectData, content, filePath string) {
 	lines := strings.Split(content, "\n")
+	commentLines := NewViewTreeParser().CommentLines(content)
+	declarations := BuildComponentDecls(ParseTree(content), filePath)
 	var currentComponent string
 	
-	ps.projectData.mutex.Lock()
-	defer ps.projectData.mutex.Unlock()
+	data.mutex.Lock()
+	defer data.mutex.Unlock()
 	
 	// Clear previous components for this file
-	if components, exists := ps.projectData.FileComponents[filePath]; exists {
+	if components, exists := data.FileComponents[filePath]; exists {
 		for comp := range components {
-			if ps.projectData.ComponentFiles[comp] == filePath {
-				delete(ps.projectData.ComponentFiles, comp)
+			if data.ComponentFiles[comp] == filePath {
+				delete(data.ComponentFiles, comp)
+			}
+			if decl := data.Declarations[comp]; decl != nil && decl.File == filePath {
+				delete(data.Declarations, comp)
 			}
 		}
 	}
-	ps.projectData.FileComponents[filePath] = make(map[string]bool)
+	data.FileComponents[filePath] = make(map[string]bool)
+	data.namespaces = nil
+	
+	for _, decl := range declarations {
+		data.Declarations[decl.Name] = decl
+	}
 	
-	for _, line := range lines {
+	for lineIndex, line := range lines {
+		if commentLines[lineIndex] {
+			continue
+		}
 		trimmed := strings.TrimSpace(line)
 		
 		// Take only the first word from lines without indentation (tabs only)
@@ -181,12 +405,12 @@ func (ps *ProjectScanner) parseViewTreeFile(content, filePath string) {
 				firstWord := fields[0]
 				if strings.HasPrefix(firstWord, "$") {
 					currentComponent = firstWord
-					ps.projectData.Components[firstWord] = true
-					ps.projectData.ComponentFiles[firstWord] = filePath
-					ps.projectData.FileComponents[filePath][firstWord] = true
+					data.Components[firstWord] = true
+					data.ComponentFiles[firstWord] = filePath
+					data.FileComponents[filePath][firstWord] = true
 					
-					if _, exists := ps.projectData.ComponentProperties[firstWord]; !exists {
-						ps.projectData.ComponentProperties[firstWord] = make(map[string]bool)
+					if _, exists := data.ComponentProperties[firstWord]; !exists {
+						data.ComponentProperties[firstWord] = make(map[string]bool)
 					}
 				}
 			}
@@ -204,7 +428,7 @@ func (ps *ProjectScanner) parseViewTreeFile(content, filePath string) {
 					property := propertyMatch[1]
 					if property != "" && !strings.HasPrefix(property, "$") && 
 					   property != "null" && property != "true" && property != "false" {
-						ps.projectData.ComponentProperties[currentComponent][property] = true
+						data.ComponentProperties[currentComponent][property] = true
 					}
 				}
 			}
@@ -220,7 +444,7 @@ func (ps *ProjectScanner) parseViewTreeFile(content, filePath string) {
 				if bindingMatch := regexp.MustCompile(pattern).FindStringSubmatch(trimmed); len(bindingMatch) > 1 {
 					property := bindingMatch[1]
 					if property != "" && !strings.HasPrefix(property, "$") {
-						ps.projectData.ComponentProperties[currentComponent][property] = true
+						data.ComponentProperties[currentComponent][property] = true
 					}
 				}
 			}
@@ -229,6 +453,10 @@ func (ps *ProjectScanner) parseViewTreeFile(content, filePath string) {
 }
 
 func (ps *ProjectScanner) parseTsFile(content, filePath string) {
+	ps.parseTsInto(ps.project(), content, filePath)
+}
+
+func (ps *ProjectScanner) parseTsInto(data *ProjectData, content, filePath string) {
 	// Look for all $ components in TypeScript files
 	componentRegex := regexp.MustCompile(`\$\w+`)
 	matches := componentRegex.FindAllString(content, -1)
@@ -237,30 +465,58 @@ func (ps *ProjectScanner) parseTsFile(content, filePath string) {
 		return
 	}
 	
-	ps.projectData.mutex.Lock()
-	defer ps.projectData.mutex.Unlock()
+	classes := ParseTsClasses(content, filePath)
+	
+	data.mutex.Lock()
+	defer data.mutex.Unlock()
 	
 	// Clear previous components for this file
-	if components, exists := ps.projectData.FileComponents[filePath]; exists {
+	if components, exists := data.FileComponents[filePath]; exists {
 		for comp := range components {
-			if ps.projectData.ComponentFiles[comp] == filePath {
-				delete(ps.projectData.ComponentFiles, comp)
+			if data.ComponentFiles[comp] == filePath {
+				delete(data.ComponentFiles, comp)
+			}
+			if class := data.TsClasses[comp]; class != nil && class.File == filePath {
+				delete(data.TsClasses, comp)
 			}
 		}
 	}
-	ps.projectData.FileComponents[filePath] = make(map[string]bool)
+	data.FileComponents[filePath] = make(map[string]bool)
+	
+	for _, class := range classes {
+		data.TsClasses[class.Name] = class
+	}
 	
 	for _, match := range matches {
-		ps.projectData.Components[match] = true
+		data.Components[match] = true
 		// Only set file mapping if not already set by .view.tree file
-		if _, exists := ps.projectData.ComponentFiles[match]; !exists {
-			ps.projectData.ComponentFiles[match] = filePath
+		if _, exists := data.ComponentFiles[match]; !exists {
+			data.ComponentFiles[match] = filePath
 		}
-		ps.projectData.FileComponents[filePath][match] = true
+		data.FileComponents[filePath][match] = true
 	}
 }
 
 func (ps *ProjectScanner) UpdateSingleFile(filePath, content string) {
+	// Ignore rules are reloaded on next use
+	if isIgnoreRulesFile(filePath) {
+		ps.resetIgnoreMatchers()
+		return
+	}
+	if !strings.HasSuffix(filePath, ".view.tree") && !strings.HasSuffix(filePath, ".ts") {
+		return
+	}
+	
+	// Library roots are indexed read-only
+	if ps.isLibraryPath(filePath) {
+		return
+	}
+	
+	// Build outputs and ignored files never shadow real sources
+	if ps.isSkippedFile(filePath) {
+		return
+	}
+	
 	log.Printf("[view.tree] Updating single file: %s", filePath)
 	
 	if strings.HasSuffix(filePath, ".view.tree") {
@@ -268,32 +524,46 @@ func (ps *ProjectScanner) UpdateSingleFile(filePath, content string) {
 	} else if strings.HasSuffix(filePath, ".ts") {
 		ps.parseTsFile(content, filePath)
 	}
+	
+	ps.stateMutex.Lock()
+	ps.revision++
+	ps.revisionFile = filePath
+	ps.stateMutex.Unlock()
+}
+
+// indexRevision returns the revision of the index and the file updated last
+func (ps *ProjectScanner) indexRevision() (uint64, string) {
+	ps.stateMutex.RLock()
+	defer ps.stateMutex.RUnlock()
+	
+	return ps.revision, ps.revisionFile
 }
 
 func (ps *ProjectScanner) GetProjectData() *ProjectData {
-	return ps.projectData
+	return ps.project()
 }
 
 func (ps *ProjectScanner) GetComponentsStartingWith(prefix string) []string {
-	ps.projectData.mutex.RLock()
-	defer ps.projectData.mutex.RUnlock()
-	
 	var components []string
-	for component := range ps.projectData.Components {
+	for _, component := range ps.GetComponents() {
 		if strings.HasPrefix(component, prefix) {
 			components = append(components, component)
 		}
 	}
 	
-	sort.Strings(components)
 	return components
 }
 
 func (ps *ProjectScanner) GetPropertiesForComponent(component string) []string {
-	ps.projectData.mutex.RLock()
-	defer ps.projectData.mutex.RUnlock()
+	data := ps.dataFor(component)
+	if data == nil {
+		return []string{}
+	}
 	
-	properties, exists := ps.projectData.ComponentProperties[component]
+	data.mutex.RLock()
+	defer data.mutex.RUnlock()
+	
+	properties, exists := data.ComponentProperties[component]
 	if !exists {
 		return []string{}
 	}
@@ -308,14 +578,19 @@ func (ps *ProjectScanner) GetPropertiesForComponent(component string) []string {
 }
 
 func (ps *ProjectScanner) GetAllProperties() []string {
-	ps.projectData.mutex.RLock()
-	defer ps.projectData.mutex.RUnlock()
-	
 	allProperties := make(map[string]bool)
-	for _, properties := range ps.projectData.ComponentProperties {
-		for property := range properties {
-			allProperties[property] = true
+	for _, scanner := range ps.visibleScanners() {
+		data := scanner.project()
+		data.mutex.RLock()
+		for component, properties := range data.ComponentProperties {
+			if scanner != ps && !data.declaresNamespaceLocked(componentNamespace(component)) {
+				continue
+			}
+			for property := range properties {
+				allProperties[property] = true
+			}
 		}
+		data.mutex.RUnlock()
 	}
 	
 	var result []string
@@ -328,20 +603,45 @@ func (ps *ProjectScanner) GetAllProperties() []string {
 }
 
 func (ps *ProjectScanner) GetComponentFile(component string) string {
-	ps.projectData.mutex.RLock()
-	defer ps.projectData.mutex.RUnlock()
+	data := ps.dataFor(component)
+	if data == nil {
+		return ""
+	}
+	
+	data.mutex.RLock()
+	defer data.mutex.RUnlock()
 	
-	return ps.projectData.ComponentFiles[component]
+	return data.ComponentFiles[component]
 }
 
-// GetComponents returns all components
+// GetComponents returns all components: this root, peer roots sharing a
+// namespace with them and library roots
 func (ps *ProjectScanner) GetComponents() []string {
-	ps.projectData.mutex.RLock()
-	defer ps.projectData.mutex.RUnlock()
-	
+	seen := make(map[string]bool)
 	var components []string
-	for component := range ps.projectData.Components {
-		components = append(components, component)
+	
+	collect := func(data *ProjectData, accept func(component string) bool) {
+		data.mutex.RLock()
+		defer data.mutex.RUnlock()
+		
+		for component := range data.Components {
+			if !seen[component] && accept(component) {
+				seen[component] = true
+				components = append(components, component)
+			}
+		}
+	}
+	
+	scanners := ps.visibleScanners()
+	for _, scanner := range scanners {
+		isPeer := scanner != ps
+		data := scanner.project()
+		collect(data, func(component string) bool {
+			return !isPeer || data.declaresNamespaceLocked(componentNamespace(component))
+		})
+	}
+	for _, scanner := range scanners {
+		collect(scanner.library(), func(string) bool { return true })
 	}
 	
 	sort.Strings(components)
@@ -350,8 +650,136 @@ func (ps *ProjectScanner) GetComponents() []string {
 
 // HasComponent checks if a component exists
 func (ps *ProjectScanner) HasComponent(component string) bool {
-	ps.projectData.mutex.RLock()
-	defer ps.projectData.mutex.RUnlock()
+	return ps.dataFor(component) != nil
+}
+
+// IsLibraryComponent reports whether a component is only known from library roots
+func (ps *ProjectScanner) IsLibraryComponent(component string) bool {
+	data := ps.dataFor(component)
+	if data == nil {
+		return false
+	}
 	
-	return ps.projectData.Components[component]
-}
\ No newline at end of file
+	for _, scanner := range ps.visibleScanners() {
+		if data == scanner.library() {
+			return true
+		}
+	}
+	return false
+}
+
+// dataFor returns the index that holds the component: this root first, then
+// peer roots declaring the component's namespace, then library roots
+func (ps *ProjectScanner) dataFor(component string) *ProjectData {
+	for _, data := range ps.searchOrder(component) {
+		if hasComponent(data, component) {
+			return data
+		}
+	}
+	return nil
+}
+
+// searchOrder lists the indexes that may hold the component by priority
+func (ps *ProjectScanner) searchOrder(component string) []*ProjectData {
+	order := []*ProjectData{ps.project()}
+	
+	scanners := ps.visibleScanners()
+	namespace := componentNamespace(component)
+	for _, peer := range scanners {
+		if peer == ps {
+			continue
+		}
+		if data := peer.project(); data.declaresNamespace(namespace) {
+			order = append(order, data)
+		}
+	}
+	
+	for _, scanner := range scanners {
+		order = append(order, scanner.library())
+	}
+	
+	return order
+}
+
+// project returns the index of the workspace sources
+func (ps *ProjectScanner) project() *ProjectData {
+	ps.stateMutex.RLock()
+	defer ps.stateMutex.RUnlock()
+	
+	return ps.projectData
+}
+
+// library returns the read-only library index
+func (ps *ProjectScanner) library() *ProjectData {
+	ps.stateMutex.RLock()
+	defer ps.stateMutex.RUnlock()
+	
+	return ps.libraryData
+}
+
+func hasComponent(data *ProjectData, component string) bool {
+	data.mutex.RLock()
+	defer data.mutex.RUnlock()
+	
+	return data.Components[component]
+}
+
+// visibleScanners returns this scanner followed by its peers
+func (ps *ProjectScanner) visibleScanners() []*ProjectScanner {
+	ps.stateMutex.RLock()
+	peers := ps.peers
+	ps.stateMutex.RUnlock()
+	
+	scanners := []*ProjectScanner{ps}
+	if peers == nil {
+		return scanners
+	}
+	for _, peer := range peers() {
+		if peer != ps {
+			scanners = append(scanners, peer)
+		}
+	}
+	return scanners
+}
+
+// declaresNamespace reports whether a .view.tree file of the index declares
+// a component in the namespace
+func (data *ProjectData) declaresNamespace(namespace string) bool {
+	data.mutex.RLock()
+	defer data.mutex.RUnlock()
+	
+	return data.declaresNamespaceLocked(namespace)
+}
+
+func (data *ProjectData) declaresNamespaceLocked(namespace string) bool {
+	if namespace == "" {
+		return false
+	}
+	return data.viewTreeNamespaces()[namespace]
+}
+
+// viewTreeNamespaces returns the namespaces declared by .view.tree files.
+// The caller holds data.mutex; writers reset the set under the write lock.
+func (data *ProjectData) viewTreeNamespaces() map[string]bool {
+	data.namespaceMutex.Lock()
+	defer data.namespaceMutex.Unlock()
+	
+	if data.namespaces == nil {
+		data.namespaces = make(map[string]bool)
+		for component, filePath := range data.ComponentFiles {
+			if strings.HasSuffix(filePath, ".view.tree") {
+				data.namespaces[componentNamespace(component)] = true
+			}
+		}
+	}
+	return data.namespaces
+}
+
+// componentNamespace returns the first segment of a component name: "$mol_button" -> "mol"
+func componentNamespace(component string) string {
+	name := strings.TrimPrefix(component, "$")
+	if index := strings.Index(name, "_"); index >= 0 {
+		return name[:index]
+	}
+	return name
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -62,6 +64,11 @@ type WorkspaceClientCapabilities struct {
 	ExecuteCommand         *ExecuteCommandCapabilities `json:"executeCommand,omitempty"`
 	Configuration          bool                        `json:"configuration,omitempty"`
 	WorkspaceFolders       bool                        `json:"workspaceFolders,omitempty"`
+	InlayHint              *RefreshCapabilities        `json:"inlayHint,omitempty"`
+}
+
+type RefreshCapabilities struct {
+	RefreshSupport bool `json:"refreshSupport,omitempty"`
 }
 
 type WorkspaceEditCapabilities struct {
@@ -372,6 +379,9 @@ type ServerCapabilities struct {
 	ExecuteCommandProvider           *ExecuteCommandOptions         `json:"executeCommandProvider,omitempty"`
 	SelectionRangeProvider           interface{}                    `json:"selectionRangeProvider,omitempty"`
 	WorkspaceSymbolProvider          interface{}                    `json:"workspaceSymbolProvider,omitempty"`
+	TypeHierarchyProvider            interface{}                    `json:"typeHierarchyProvider,omitempty"`
+	CallHierarchyProvider            interface{}                    `json:"callHierarchyProvider,omitempty"`
+	InlayHintProvider                interface{}                    `json:"inlayHintProvider,omitempty"`
 	Workspace                        *WorkspaceServerCapabilities   `json:"workspace,omitempty"`
 	Experimental                     interface{}                    `json:"experimental,omitempty"`
 }
@@ -409,6 +419,11 @@ type ExecuteCommandOptions struct {
 	Commands []string `json:"commands"`
 }
 
+type ExecuteCommandParams struct {
+	Command   string            `json:"command"`
+	Arguments []json.RawMessage `json:"arguments,omitempty"`
+}
+
 type WorkspaceServerCapabilities struct {
 	WorkspaceFolders *WorkspaceFoldersServerCapabilities `json:"workspaceFolders,omitempty"`
 	FileOperations   *FileOperationOptions               `json:"fileOperations,omitempty"`
@@ -483,6 +498,13 @@ type Location struct {
 	Range Range  `json:"range"`
 }
 
+type LocationLink struct {
+	OriginSelectionRange *Range `json:"originSelectionRange,omitempty"`
+	TargetURI            string `json:"targetUri"`
+	TargetRange          Range  `json:"targetRange"`
+	TargetSelectionRange Range  `json:"targetSelectionRange"`
+}
+
 type TextDocumentIdentifier struct {
 	URI string `json:"uri"`
 }
@@ -504,6 +526,102 @@ type TextDocumentPositionParams struct {
 	Position     Position               `json:"position"`
 }
 
+type SignatureHelpParams struct {
+	TextDocumentPositionParams
+	WorkDoneProgressParams
+	Context *SignatureHelpContext `json:"context,omitempty"`
+}
+
+type SignatureHelpContext struct {
+	TriggerKind         int            `json:"triggerKind"`
+	TriggerCharacter    string         `json:"triggerCharacter,omitempty"`
+	IsRetrigger         bool           `json:"isRetrigger"`
+	ActiveSignatureHelp *SignatureHelp `json:"activeSignatureHelp,omitempty"`
+}
+
+type SignatureHelp struct {
+	Signatures      []SignatureInformation `json:"signatures"`
+	ActiveSignature int                    `json:"activeSignature"`
+	ActiveParameter int                    `json:"activeParameter"`
+}
+
+type SignatureInformation struct {
+	Label         string                 `json:"label"`
+	Documentation interface{}            `json:"documentation,omitempty"`
+	Parameters    []ParameterInformation `json:"parameters,omitempty"`
+}
+
+type ParameterInformation struct {
+	Label         string      `json:"label"`
+	Documentation interface{} `json:"documentation,omitempty"`
+}
+
+type InlayHintParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+}
+
+type InlayHintKind int
+
+const (
+	InlayHintKindType      InlayHintKind = 1
+	InlayHintKindParameter InlayHintKind = 2
+)
+
+type InlayHint struct {
+	Position     Position      `json:"position"`
+	Label        string        `json:"label"`
+	Kind         InlayHintKind `json:"kind,omitempty"`
+	PaddingLeft  bool          `json:"paddingLeft,omitempty"`
+	PaddingRight bool          `json:"paddingRight,omitempty"`
+}
+
+type CodeLensParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type CodeLens struct {
+	Range   Range       `json:"range"`
+	Command *Command    `json:"command,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+type FoldingRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+const (
+	FoldingRangeKindComment = "comment"
+	FoldingRangeKindImports = "imports"
+	FoldingRangeKindRegion  = "region"
+)
+
+type FoldingRange struct {
+	StartLine      int    `json:"startLine"`
+	StartCharacter *int   `json:"startCharacter,omitempty"`
+	EndLine        int    `json:"endLine"`
+	EndCharacter   *int   `json:"endCharacter,omitempty"`
+	Kind           string `json:"kind,omitempty"`
+}
+
+type DeclarationParams struct {
+	TextDocumentPositionParams
+	WorkDoneProgressParams
+	PartialResultParams
+}
+
+type TypeDefinitionParams struct {
+	TextDocumentPositionParams
+	WorkDoneProgressParams
+	PartialResultParams
+}
+
+type ImplementationParams struct {
+	TextDocumentPositionParams
+	WorkDoneProgressParams
+	PartialResultParams
+}
+
 type DefinitionParams struct {
 	TextDocumentPositionParams
 	WorkDoneProgressParams
@@ -641,6 +759,14 @@ type TextEdit struct {
 	NewText string `json:"newText"`
 }
 
+// InsertReplaceEdit lets the client either insert before the cursor or
+// replace the whole word
+type InsertReplaceEdit struct {
+	NewText string `json:"newText"`
+	Insert  Range  `json:"insert"`
+	Replace Range  `json:"replace"`
+}
+
 type Command struct {
 	Title     string        `json:"title"`
 	Command   string        `json:"command"`
@@ -696,6 +822,123 @@ type PublishDiagnosticsParams struct {
 	Diagnostics []Diagnostic `json:"diagnostics"`
 }
 
+// Code action structures
+type CodeActionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Context      CodeActionContext      `json:"context"`
+	WorkDoneProgressParams
+	PartialResultParams
+}
+
+type CodeActionContext struct {
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	Only        []string     `json:"only,omitempty"`
+}
+
+const (
+	CodeActionKindQuickFix = "quickfix"
+)
+
+type CodeAction struct {
+	Title       string         `json:"title"`
+	Kind        string         `json:"kind,omitempty"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"`
+	IsPreferred bool           `json:"isPreferred,omitempty"`
+	Edit        *WorkspaceEdit `json:"edit,omitempty"`
+	Command     *Command       `json:"command,omitempty"`
+}
+
+type WorkspaceEdit struct {
+	Changes map[string][]TextEdit `json:"changes,omitempty"`
+}
+
+// Type hierarchy structures
+type SymbolKind int
+
+const (
+	SymbolKindClass    SymbolKind = 5
+	SymbolKindMethod   SymbolKind = 6
+	SymbolKindProperty SymbolKind = 7
+)
+
+type TypeHierarchyPrepareParams struct {
+	TextDocumentPositionParams
+	WorkDoneProgressParams
+}
+
+type TypeHierarchyItem struct {
+	Name           string         `json:"name"`
+	Kind           SymbolKind     `json:"kind"`
+	Detail         string         `json:"detail,omitempty"`
+	URI            string         `json:"uri"`
+	Range          Range          `json:"range"`
+	SelectionRange Range          `json:"selectionRange"`
+	Data           *HierarchyData `json:"data,omitempty"`
+}
+
+// HierarchyData identifies the class behind a hierarchy item between requests
+type HierarchyData struct {
+	Component string `json:"component"`
+	Source    string `json:"source"`             // HierarchySourceTree or HierarchySourceTs
+	Property  string `json:"property,omitempty"` // call hierarchy items, e.g. "Selector.value"
+}
+
+const (
+	HierarchySourceTree = "view.tree"
+	HierarchySourceTs   = "ts"
+)
+
+type TypeHierarchySupertypesParams struct {
+	Item TypeHierarchyItem `json:"item"`
+	WorkDoneProgressParams
+	PartialResultParams
+}
+
+type TypeHierarchySubtypesParams struct {
+	Item TypeHierarchyItem `json:"item"`
+	WorkDoneProgressParams
+	PartialResultParams
+}
+
+// Call hierarchy structures
+type CallHierarchyPrepareParams struct {
+	TextDocumentPositionParams
+	WorkDoneProgressParams
+}
+
+type CallHierarchyItem struct {
+	Name           string         `json:"name"`
+	Kind           SymbolKind     `json:"kind"`
+	Detail         string         `json:"detail,omitempty"`
+	URI            string         `json:"uri"`
+	Range          Range          `json:"range"`
+	SelectionRange Range          `json:"selectionRange"`
+	Data           *HierarchyData `json:"data,omitempty"`
+}
+
+type CallHierarchyIncomingCallsParams struct {
+	Item CallHierarchyItem `json:"item"`
+	WorkDoneProgressParams
+	PartialResultParams
+}
+
+type CallHierarchyIncomingCall struct {
+	From       CallHierarchyItem `json:"from"`
+	FromRanges []Range           `json:"fromRanges"`
+}
+
+type CallHierarchyOutgoingCallsParams struct {
+	Item CallHierarchyItem `json:"item"`
+	WorkDoneProgressParams
+	PartialResultParams
+}
+
+type CallHierarchyOutgoingCall struct {
+	To         CallHierarchyItem `json:"to"`
+	FromRanges []Range           `json:"fromRanges"`
+}
+
 // Document Change structures
 type DidOpenTextDocumentParams struct {
 	TextDocument TextDocumentItem `json:"textDocument"`
@@ -716,6 +959,39 @@ type DidCloseTextDocumentParams struct {
 	TextDocument TextDocumentIdentifier `json:"textDocument"`
 }
 
+// Configuration structures
+type ConfigurationParams struct {
+	Items []ConfigurationItem `json:"items"`
+}
+
+type ConfigurationItem struct {
+	ScopeURI string `json:"scopeUri,omitempty"`
+	Section  string `json:"section,omitempty"`
+}
+
+type DidChangeWorkspaceFoldersParams struct {
+	Event WorkspaceFoldersChangeEvent `json:"event"`
+}
+
+type WorkspaceFoldersChangeEvent struct {
+	Added   []WorkspaceFolder `json:"added"`
+	Removed []WorkspaceFolder `json:"removed"`
+}
+
+type DidChangeConfigurationParams struct {
+	Settings interface{} `json:"settings"`
+}
+
+type RegistrationParams struct {
+	Registrations []Registration `json:"registrations"`
+}
+
+type Registration struct {
+	ID              string      `json:"id"`
+	Method          string      `json:"method"`
+	RegisterOptions interface{} `json:"registerOptions,omitempty"`
+}
+
 // Server struct and main implementation
 type Server struct {
 	reader io.Reader
@@ -724,19 +1000,37 @@ type Server struct {
 	// Client capabilities
 	hasConfigurationCapability   bool
 	hasWorkspaceFolderCapability bool
-
-	// Workspace info
-	workspaceRoot string
+	hasConfigurationRegistration bool
+	hasInlayHintRefreshSupport   bool
+	hasDefinitionLinkSupport     bool
+	hasDeclarationLinkSupport    bool
+	hasTypeDefinitionLinkSupport bool
+	hasImplementationLinkSupport bool
+	completionClient             CompletionClient
+	foldingRangeClient           FoldingRangeCapabilities
+
+	// Workspace info. workspaceRoot is the first folder
+	workspaceRoot    string
+	workspaceFolders []WorkspaceFolder
 
 	// Document store
 	documents sync.Map
+	
+	// Settings pulled from or pushed by the client. They are applied on the
+	// message loop and read by the scans running in the background. Without
+	// client settings every folder reads its own .viewtreerc.json instead.
+	settings         *Settings
+	settingsFromFile bool
+	settingsMutex    sync.RWMutex
+	
+	// Requests sent to the client, waiting for a response
+	nextRequestID   int
+	pendingRequests map[string]func(result interface{}, err *LSPError)
+	requestMutex    sync.Mutex
+	writeMutex      sync.Mutex
 
-	// Providers
-	projectScanner     *ProjectScanner
-	definitionProvider *DefinitionProvider
-	completionProvider *CompletionProvider
-	hoverProvider      *HoverProvider
-	diagnosticProvider *DiagnosticProvider
+	// One project index and set of providers per workspace folder
+	workspaces *WorkspaceManager
 }
 
 type TextDocument struct {
@@ -748,8 +1042,11 @@ type TextDocument struct {
 
 func NewServer() *Server {
 	return &Server{
-		reader: os.Stdin,
-		writer: os.Stdout,
+		reader:          os.Stdin,
+		writer:          os.Stdout,
+		settings:        DefaultSettings(),
+		workspaces:      NewWorkspaceManager(),
+		pendingRequests: make(map[string]func(result interface{}, err *LSPError)),
 	}
 }
 
@@ -812,6 +1109,11 @@ func (s *Server) handleMessage(content []byte) error {
 		return fmt.Errorf("failed to unmarshal message: %w", err)
 	}
 	
+	// Responses to requests sent by the server have no method
+	if msg.Method == "" && msg.ID != nil {
+		return s.handleResponse(msg)
+	}
+	
 	log.Printf("[view.tree] Received %s", msg.Method)
 	
 	switch msg.Method {
@@ -827,10 +1129,48 @@ func (s *Server) handleMessage(content []byte) error {
 		return s.handleDidClose(msg)
 	case "textDocument/completion":
 		return s.handleCompletion(msg)
+	case "completionItem/resolve":
+		return s.handleCompletionResolve(msg)
 	case "textDocument/definition":
 		return s.handleDefinition(msg)
+	case "textDocument/inlayHint":
+		return s.handleInlayHint(msg)
+	case "textDocument/codeLens":
+		return s.handleCodeLens(msg)
+	case "codeLens/resolve":
+		return s.handleCodeLensResolve(msg)
+	case "textDocument/foldingRange":
+		return s.handleFoldingRange(msg)
+	case "textDocument/signatureHelp":
+		return s.handleSignatureHelp(msg)
+	case "textDocument/declaration":
+		return s.handleDeclaration(msg)
+	case "textDocument/typeDefinition":
+		return s.handleTypeDefinition(msg)
+	case "textDocument/implementation":
+		return s.handleImplementation(msg)
+	case "textDocument/prepareTypeHierarchy":
+		return s.handlePrepareTypeHierarchy(msg)
+	case "typeHierarchy/supertypes":
+		return s.handleTypeHierarchySupertypes(msg)
+	case "typeHierarchy/subtypes":
+		return s.handleTypeHierarchySubtypes(msg)
+	case "textDocument/prepareCallHierarchy":
+		return s.handlePrepareCallHierarchy(msg)
+	case "callHierarchy/incomingCalls":
+		return s.handleIncomingCalls(msg)
+	case "callHierarchy/outgoingCalls":
+		return s.handleOutgoingCalls(msg)
 	case "textDocument/hover":
 		return s.handleHover(msg)
+	case "textDocument/codeAction":
+		return s.handleCodeAction(msg)
+	case "workspace/executeCommand":
+		return s.handleExecuteCommand(msg)
+	case "workspace/didChangeConfiguration":
+		return s.handleDidChangeConfiguration(msg)
+	case "workspace/didChangeWorkspaceFolders":
+		return s.handleDidChangeWorkspaceFolders(msg)
 	case "shutdown":
 		return s.handleShutdown(msg)
 	case "exit":
@@ -862,12 +1202,53 @@ func (s *Server) sendNotification(method string, params interface{}) error {
 	return s.sendMessage(notification)
 }
 
+// sendRequest sends a request to the client. The callback is invoked from the
+// message loop when the matching response arrives.
+func (s *Server) sendRequest(method string, params interface{}, callback func(result interface{}, err *LSPError)) error {
+	s.requestMutex.Lock()
+	s.nextRequestID++
+	id := s.nextRequestID
+	if callback != nil {
+		s.pendingRequests[strconv.Itoa(id)] = callback
+	}
+	s.requestMutex.Unlock()
+	
+	request := LSPMessage{
+		JSONRPC: "2.0",
+		ID:      id,
+		Method:  method,
+		Params:  params,
+	}
+	
+	return s.sendMessage(request)
+}
+
+func (s *Server) handleResponse(msg LSPMessage) error {
+	key := fmt.Sprint(msg.ID)
+	
+	s.requestMutex.Lock()
+	callback, ok := s.pendingRequests[key]
+	delete(s.pendingRequests, key)
+	s.requestMutex.Unlock()
+	
+	if !ok {
+		log.Printf("[view.tree] Response for unknown request: %s", key)
+		return nil
+	}
+	
+	callback(msg.Result, msg.Error)
+	return nil
+}
+
 func (s *Server) sendMessage(msg LSPMessage) error {
 	data, err := json.Marshal(msg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 	
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+	
 	header := fmt.Sprintf("Content-Length: %d\r\n\r\n", len(data))
 	
 	if _, err := s.writer.Write([]byte(header)); err != nil {
@@ -887,23 +1268,54 @@ func (s *Server) handleInitialize(msg LSPMessage) error {
 		return err
 	}
 	
-	// Extract workspace root
-	if params.RootURI != nil && *params.RootURI != "" {
-		s.workspaceRoot = s.uriToFilePath(*params.RootURI)
+	// Extract workspace folders, falling back to the single root
+	if len(params.WorkspaceFolders) > 0 {
+		s.workspaceFolders = params.WorkspaceFolders
+	} else if params.RootURI != nil && *params.RootURI != "" {
+		s.workspaceFolders = []WorkspaceFolder{{URI: *params.RootURI, Name: filepath.Base(s.uriToFilePath(*params.RootURI))}}
 	} else if params.RootPath != nil && *params.RootPath != "" {
-		s.workspaceRoot = *params.RootPath
-	} else if len(params.WorkspaceFolders) > 0 {
-		s.workspaceRoot = s.uriToFilePath(params.WorkspaceFolders[0].URI)
+		s.workspaceFolders = []WorkspaceFolder{{URI: *params.RootPath, Name: filepath.Base(*params.RootPath)}}
 	} else {
-		s.workspaceRoot = "."
+		s.workspaceFolders = []WorkspaceFolder{{URI: ".", Name: "."}}
 	}
+	s.workspaceRoot = s.uriToFilePath(s.workspaceFolders[0].URI)
 	
-	log.Printf("[view.tree] Workspace root set to: %s", s.workspaceRoot)
+	log.Printf("[view.tree] Workspace root set to: %s (%d folders)", s.workspaceRoot, len(s.workspaceFolders))
 	
 	// Check client capabilities
 	if params.Capabilities.Workspace != nil {
 		s.hasConfigurationCapability = params.Capabilities.Workspace.Configuration
 		s.hasWorkspaceFolderCapability = params.Capabilities.Workspace.WorkspaceFolders
+		s.hasConfigurationRegistration = params.Capabilities.Workspace.DidChangeConfiguration != nil &&
+			params.Capabilities.Workspace.DidChangeConfiguration.DynamicRegistration
+		s.hasInlayHintRefreshSupport = params.Capabilities.Workspace.InlayHint != nil &&
+			params.Capabilities.Workspace.InlayHint.RefreshSupport
+	}
+	
+	if textDocument := params.Capabilities.TextDocument; textDocument != nil {
+		s.hasDefinitionLinkSupport = textDocument.Definition != nil && textDocument.Definition.LinkSupport
+		s.hasDeclarationLinkSupport = textDocument.Declaration != nil && textDocument.Declaration.LinkSupport
+		s.hasTypeDefinitionLinkSupport = textDocument.TypeDefinition != nil && textDocument.TypeDefinition.LinkSupport
+		s.hasImplementationLinkSupport = textDocument.Implementation != nil && textDocument.Implementation.LinkSupport
+		if textDocument.FoldingRange != nil {
+			s.foldingRangeClient = *textDocument.FoldingRange
+		}
+		
+		if textDocument.Completion != nil && textDocument.Completion.CompletionItem != nil {
+			completionItem := textDocument.Completion.CompletionItem
+			s.completionClient = CompletionClient{
+				SnippetSupport:          completionItem.SnippetSupport,
+				InsertReplaceSupport:    completionItem.InsertReplaceSupport,
+				CommitCharactersSupport: completionItem.CommitCharactersSupport,
+			}
+			if completionItem.InsertTextModeSupport != nil {
+				for _, mode := range completionItem.InsertTextModeSupport.ValueSet {
+					if InsertTextMode(mode) == InsertTextModeAdjustIndentation {
+						s.completionClient.AdjustIndentationSupport = true
+					}
+				}
+			}
+		}
 	}
 	
 	result := InitializeResult{
@@ -913,8 +1325,26 @@ func (s *Server) handleInitialize(msg LSPMessage) error {
 				ResolveProvider:   true,
 				TriggerCharacters: []string{"$", "_", " ", "\t"},
 			},
-			DefinitionProvider: true,
-			HoverProvider:      true,
+			DefinitionProvider:     true,
+			DeclarationProvider:    true,
+			TypeDefinitionProvider: true,
+			ImplementationProvider: true,
+			TypeHierarchyProvider:  true,
+			CallHierarchyProvider:  true,
+			InlayHintProvider:      true,
+			HoverProvider:          true,
+			CodeLensProvider:       &CodeLensOptions{ResolveProvider: true},
+			FoldingRangeProvider:   true,
+			CodeActionProvider: map[string]interface{}{
+				"codeActionKinds": []string{CodeActionKindQuickFix},
+			},
+			SignatureHelpProvider: &SignatureHelpOptions{
+				TriggerCharacters:   []string{"?", "*"},
+				RetriggerCharacters: []string{" "},
+			},
+			ExecuteCommandProvider: &ExecuteCommandOptions{
+				Commands: []string{CompletionAcceptedCommand},
+			},
 		},
 		ServerInfo: &ServerInfo{
 			Name:    "view.tree LSP Server",
@@ -927,7 +1357,8 @@ func (s *Server) handleInitialize(msg LSPMessage) error {
 			result.Capabilities.Workspace = &WorkspaceServerCapabilities{}
 		}
 		result.Capabilities.Workspace.WorkspaceFolders = &WorkspaceFoldersServerCapabilities{
-			Supported: true,
+			Supported:           true,
+			ChangeNotifications: true,
 		}
 	}
 	
@@ -948,8 +1379,168 @@ func (s *Server) handleInitialized(msg LSPMessage) error {
 		if err := s.initializeProviders(); err != nil {
 			log.Printf("[view.tree] Failed to initialize providers: %v", err)
 		}
+		
+		s.loadSettings()
 	}()
 	
+	if s.hasConfigurationRegistration {
+		registration := RegistrationParams{
+			Registrations: []Registration{{
+				ID:     "viewTree.didChangeConfiguration",
+				Method: "workspace/didChangeConfiguration",
+			}},
+		}
+		if err := s.sendRequest("client/registerCapability", registration, nil); err != nil {
+			log.Printf("[view.tree] Failed to register configuration change notifications: %v", err)
+		}
+	}
+	
+	return nil
+}
+
+// loadSettings pulls settings from the client. Clients without
+// workspace/configuration support get the .viewtreerc.json of each folder.
+func (s *Server) loadSettings() {
+	if !s.hasConfigurationCapability {
+		s.settingsMutex.Lock()
+		s.settingsFromFile = true
+		s.settingsMutex.Unlock()
+		
+		s.updateSettings(func(workspace *Workspace) *Settings {
+			return folderSettings(workspace.Root, workspace.projectScanner.Settings())
+		})
+		return
+	}
+	
+	params := ConfigurationParams{
+		Items: []ConfigurationItem{{Section: SettingsSection}},
+	}
+	
+	err := s.sendRequest("workspace/configuration", params, func(result interface{}, lspErr *LSPError) {
+		if lspErr != nil {
+			log.Printf("[view.tree] workspace/configuration failed: %s", lspErr.Message)
+			return
+		}
+		
+		var raw interface{}
+		if items, ok := result.([]interface{}); ok && len(items) > 0 {
+			raw = items[0]
+		}
+		
+		settings, err := ParseSettings(raw)
+		if err != nil {
+			log.Printf("[view.tree] Invalid settings: %v", err)
+			return
+		}
+		s.applySettings(settings)
+	})
+	if err != nil {
+		log.Printf("[view.tree] Failed to request configuration: %v", err)
+	}
+}
+
+// applySettings stores the client settings and applies them to every folder
+func (s *Server) applySettings(settings *Settings) {
+	s.settingsMutex.Lock()
+	s.settings = settings
+	s.settingsFromFile = false
+	s.settingsMutex.Unlock()
+	
+	s.updateSettings(func(*Workspace) *Settings {
+		return settings
+	})
+}
+
+// folderSettings reads the .viewtreerc.json of a workspace folder, keeping
+// fallback when the file is invalid
+func folderSettings(root string, fallback *Settings) *Settings {
+	settings, err := LoadSettingsFile(root)
+	if err != nil {
+		log.Printf("[view.tree] Failed to load %s from %s: %v", SettingsFileName, root, err)
+		return fallback
+	}
+	return settings
+}
+
+// settingsForLocked returns the settings of a workspace folder. The caller
+// holds settingsMutex.
+func (s *Server) settingsForLocked(root string) *Settings {
+	if s.settingsFromFile {
+		return folderSettings(root, s.settings)
+	}
+	return s.settings
+}
+
+// updateSettings gives every workspace its new settings, rescans the
+// workspaces whose scan options changed and revalidates open documents
+func (s *Server) updateSettings(settingsFor func(workspace *Workspace) *Settings) {
+	log.Printf("[view.tree] Settings applied")
+	
+	var rescan []*Workspace
+	refreshInlayHints := false
+	for _, workspace := range s.workspaces.All() {
+		settings := settingsFor(workspace)
+		previous := workspace.projectScanner.Settings()
+		workspace.projectScanner.SetSettings(settings)
+		if !previous.ScanSettingsEqual(settings) {
+			rescan = append(rescan, workspace)
+		}
+		if previous.InlayHints != settings.InlayHints {
+			refreshInlayHints = true
+		}
+	}
+	
+	// Rescan off the message loop, like the initial scan
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[view.tree] Panic while applying settings: %v", r)
+			}
+		}()
+		
+		for _, workspace := range rescan {
+			if err := workspace.projectScanner.ScanProject(); err != nil {
+				log.Printf("[view.tree] Project rescan failed for %s: %v", workspace.Root, err)
+			}
+		}
+		
+		s.documents.Range(func(_, value interface{}) bool {
+			s.validateTextDocument(value.(*TextDocument))
+			return true
+		})
+		
+		if s.hasInlayHintRefreshSupport && refreshInlayHints {
+			if err := s.sendRequest("workspace/inlayHint/refresh", nil, nil); err != nil {
+				log.Printf("[view.tree] Failed to refresh inlay hints: %v", err)
+			}
+		}
+	}()
+}
+
+func (s *Server) handleDidChangeConfiguration(msg LSPMessage) error {
+	var params DidChangeConfigurationParams
+	if err := s.unmarshalParams(msg.Params, &params); err != nil {
+		return err
+	}
+	
+	// Prefer the pull model when the client supports it
+	if s.hasConfigurationCapability {
+		s.loadSettings()
+		return nil
+	}
+	
+	if section, ok := params.Settings.(map[string]interface{}); ok {
+		if raw, exists := section[SettingsSection]; exists {
+			settings, err := ParseSettings(raw)
+			if err != nil {
+				return err
+			}
+			s.applySettings(settings)
+			return nil
+		}
+	}
+	
+	s.loadSettings()
 	return nil
 }
 
@@ -961,37 +1552,107 @@ func (s *Server) initializeProviders() error {
 		}
 	}()
 	
-	// Use workspace root from initialization
-	workspaceRoot := s.workspaceRoot
-	if workspaceRoot == "" {
-		workspaceRoot = "."
+	folders := s.workspaceFolders
+	if len(folders) == 0 {
+		folders = []WorkspaceFolder{{URI: ".", Name: "."}}
+	}
+	
+	for _, folder := range folders {
+		s.addWorkspaceFolder(folder)
+	}
+	
+	log.Println("[view.tree] LSP server initialized successfully")
+	return nil
+}
+
+// addWorkspaceFolder creates the index and providers for a folder and scans
+// it. Folders that are already registered are skipped and give nil.
+func (s *Server) addWorkspaceFolder(folder WorkspaceFolder) *Workspace {
+	root := s.uriToFilePath(folder.URI)
+	if root == "" {
+		root = "."
+	}
+	
+	log.Printf("[view.tree] Initializing with workspace: %s", root)
+	
+	if s.workspaces.Has(root) {
+		log.Printf("[view.tree] Workspace already registered: %s", root)
+		return nil
 	}
 	
-	log.Printf("[view.tree] Initializing with workspace: %s", workspaceRoot)
+	workspace := NewWorkspace(folder, root)
+	workspace.completionProvider.SetClient(s.completionClient)
 	
-	// Initialize project scanner with error handling
-	s.projectScanner = NewProjectScanner(workspaceRoot)
-	if s.projectScanner == nil {
-		log.Printf("[view.tree] Warning: Failed to create project scanner")
-		return nil // Don't fail completely, just continue without scanning
+	s.settingsMutex.RLock()
+	scanned := s.settingsForLocked(root)
+	s.settingsMutex.RUnlock()
+	workspace.projectScanner.SetSettings(scanned)
+	
+	// The folder is scanned before peers can see it, so they never look up
+	// components in a partial index
+	s.scanWorkspace(workspace)
+	
+	// Registered under the settings lock, so updateSettings either sees the
+	// workspace or the workspace sees the new settings
+	s.settingsMutex.RLock()
+	current := s.settingsForLocked(root)
+	workspace.projectScanner.SetSettings(current)
+	added := s.workspaces.Add(workspace)
+	s.settingsMutex.RUnlock()
+	if !added {
+		return nil
 	}
 	
-	// Initialize providers
-	s.definitionProvider = NewDefinitionProvider(s.projectScanner)
-	s.completionProvider = NewCompletionProvider(s.projectScanner)
-	s.hoverProvider = NewHoverProvider(s.projectScanner)
-	s.diagnosticProvider = NewDiagnosticProvider(s.projectScanner)
+	// Settings applied during the scan may change what is indexed
+	if !scanned.ScanSettingsEqual(current) {
+		s.scanWorkspace(workspace)
+	}
 	
-	// Start initial project scan with better error handling
+	return workspace
+}
+
+// scanWorkspace scans the project of a workspace. The LSP keeps working
+// without a successful scan, so failures are only logged.
+func (s *Server) scanWorkspace(workspace *Workspace) {
 	log.Println("[view.tree] Starting project scan...")
-	if err := s.projectScanner.ScanProject(); err != nil {
+	if err := workspace.projectScanner.ScanProject(); err != nil {
 		log.Printf("[view.tree] Project scan failed (continuing anyway): %v", err)
-		// Don't return error - LSP should work even without successful project scan
 	} else {
 		log.Println("[view.tree] Project scan completed successfully")
 	}
+}
+
+func (s *Server) handleDidChangeWorkspaceFolders(msg LSPMessage) error {
+	var params DidChangeWorkspaceFoldersParams
+	if err := s.unmarshalParams(msg.Params, &params); err != nil {
+		return err
+	}
+	
+	for _, folder := range params.Event.Removed {
+		root := s.uriToFilePath(folder.URI)
+		if s.workspaces.Remove(root) != nil {
+			log.Printf("[view.tree] Workspace folder removed: %s", root)
+		}
+	}
+	
+	added := params.Event.Added
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[view.tree] Panic while adding workspace folders: %v", r)
+			}
+		}()
+		
+		for _, folder := range added {
+			s.addWorkspaceFolder(folder)
+		}
+		
+		s.documents.Range(func(_, value interface{}) bool {
+			s.validateTextDocument(value.(*TextDocument))
+			return true
+		})
+	}()
 	
-	log.Println("[view.tree] LSP server initialized successfully")
 	return nil
 }
 
@@ -1011,12 +1672,8 @@ func (s *Server) handleDidOpen(msg LSPMessage) error {
 	s.documents.Store(params.TextDocument.URI, doc)
 	
 	// Update project data incrementally
-	if s.projectScanner != nil {
-		uri := params.TextDocument.URI
-		if strings.HasSuffix(uri, ".view.tree") || strings.HasSuffix(uri, ".ts") {
-			filePath := s.uriToFilePath(uri)
-			s.projectScanner.UpdateSingleFile(filePath, doc.Text)
-		}
+	if workspace := s.workspaces.ForURI(params.TextDocument.URI); workspace != nil {
+		workspace.projectScanner.UpdateSingleFile(s.uriToFilePath(params.TextDocument.URI), doc.Text)
 	}
 	
 	// Validate document
@@ -1036,8 +1693,15 @@ func (s *Server) handleDidChange(msg LSPMessage) error {
 		return fmt.Errorf("document not found: %s", params.TextDocument.URI)
 	}
 	
-	doc := docInterface.(*TextDocument)
-	doc.Version = params.TextDocument.Version
+	// Stored documents are read by background validation, so the change goes
+	// into a new document instead of the shared one
+	previous := docInterface.(*TextDocument)
+	doc := &TextDocument{
+		URI:        previous.URI,
+		LanguageID: previous.LanguageID,
+		Version:    params.TextDocument.Version,
+		Text:       previous.Text,
+	}
 	
 	// Apply changes
 	for _, change := range params.ContentChanges {
@@ -1053,12 +1717,8 @@ func (s *Server) handleDidChange(msg LSPMessage) error {
 	s.documents.Store(params.TextDocument.URI, doc)
 	
 	// Update project data incrementally
-	if s.projectScanner != nil {
-		uri := params.TextDocument.URI
-		if strings.HasSuffix(uri, ".view.tree") || strings.HasSuffix(uri, ".ts") {
-			filePath := s.uriToFilePath(uri)
-			s.projectScanner.UpdateSingleFile(filePath, doc.Text)
-		}
+	if workspace := s.workspaces.ForURI(params.TextDocument.URI); workspace != nil {
+		workspace.projectScanner.UpdateSingleFile(s.uriToFilePath(params.TextDocument.URI), doc.Text)
 	}
 	
 	// Validate document
@@ -1083,21 +1743,62 @@ func (s *Server) handleCompletion(msg LSPMessage) error {
 		return err
 	}
 	
-	var items []CompletionItem
+	list := &CompletionList{Items: []CompletionItem{}}
 	
-	if s.completionProvider != nil {
+	if workspace := s.workspaces.ForURI(params.TextDocument.URI); workspace != nil {
 		docInterface, ok := s.documents.Load(params.TextDocument.URI)
 		if ok {
 			doc := docInterface.(*TextDocument)
-			var err error
-			items, err = s.completionProvider.ProvideCompletionItems(doc, params.Position)
+			ranked, err := workspace.completionProvider.ProvideCompletionList(doc, params.Position)
 			if err != nil {
 				log.Printf("[view.tree] Error providing completion: %v", err)
+			} else {
+				list = ranked
 			}
 		}
 	}
 	
-	return s.sendResponse(msg.ID, items)
+	return s.sendResponse(msg.ID, list)
+}
+
+func (s *Server) handleCompletionResolve(msg LSPMessage) error {
+	var item CompletionItem
+	if err := s.unmarshalParams(msg.Params, &item); err != nil {
+		return err
+	}
+	
+	var data CompletionItemData
+	if raw, err := json.Marshal(item.Data); err == nil {
+		_ = json.Unmarshal(raw, &data)
+	}
+	if workspace := s.workspaces.ForURI(data.URI); workspace != nil {
+		item = workspace.completionProvider.ResolveCompletionItem(item, data)
+	}
+	
+	return s.sendResponse(msg.ID, item)
+}
+
+// handleExecuteCommand runs the commands attached to completion items
+func (s *Server) handleExecuteCommand(msg LSPMessage) error {
+	var params ExecuteCommandParams
+	if err := s.unmarshalParams(msg.Params, &params); err != nil {
+		return err
+	}
+	
+	switch params.Command {
+	case CompletionAcceptedCommand:
+		var label string
+		if len(params.Arguments) > 0 && json.Unmarshal(params.Arguments[0], &label) == nil && label != "" {
+			// Recently used items rank first in every workspace
+			for _, workspace := range s.workspaces.All() {
+				workspace.completionProvider.RecordAccepted(label)
+			}
+		}
+	default:
+		log.Printf("[view.tree] Unknown command: %s", params.Command)
+	}
+	
+	return s.sendResponse(msg.ID, nil)
 }
 
 func (s *Server) handleDefinition(msg LSPMessage) error {
@@ -1106,21 +1807,245 @@ func (s *Server) handleDefinition(msg LSPMessage) error {
 		return err
 	}
 	
-	var locations []Location
+	links := []LocationLink{}
 	
-	if s.definitionProvider != nil {
+	if workspace := s.workspaces.ForURI(params.TextDocument.URI); workspace != nil {
 		docInterface, ok := s.documents.Load(params.TextDocument.URI)
 		if ok {
 			doc := docInterface.(*TextDocument)
 			var err error
-			locations, err = s.definitionProvider.ProvideDefinition(doc, params.Position)
+			links, err = workspace.definitionProvider.ProvideDefinition(doc, params.Position)
 			if err != nil {
 				log.Printf("[view.tree] Error providing definition: %v", err)
 			}
 		}
 	}
 	
-	return s.sendResponse(msg.ID, locations)
+	return s.sendLinks(msg.ID, links, s.hasDefinitionLinkSupport)
+}
+
+func (s *Server) handleInlayHint(msg LSPMessage) error {
+	var params InlayHintParams
+	if err := s.unmarshalParams(msg.Params, &params); err != nil {
+		return err
+	}
+	
+	hints := []InlayHint{}
+	if workspace, doc := s.documentWorkspace(params.TextDocument.URI); doc != nil {
+		hints = workspace.inlayHintProvider.ProvideInlayHints(doc, params.Range)
+	}
+	return s.sendResponse(msg.ID, hints)
+}
+
+func (s *Server) handleCodeLens(msg LSPMessage) error {
+	var params CodeLensParams
+	if err := s.unmarshalParams(msg.Params, &params); err != nil {
+		return err
+	}
+	
+	lenses := []CodeLens{}
+	if workspace, doc := s.documentWorkspace(params.TextDocument.URI); doc != nil {
+		lenses = workspace.codeLensProvider.ProvideCodeLenses(doc)
+	}
+	return s.sendResponse(msg.ID, lenses)
+}
+
+// handleCodeLensResolve counts the references of a lens from the project index
+func (s *Server) handleCodeLensResolve(msg LSPMessage) error {
+	var lens CodeLens
+	if err := s.unmarshalParams(msg.Params, &lens); err != nil {
+		return err
+	}
+	
+	var data CodeLensData
+	if raw, err := json.Marshal(lens.Data); err == nil {
+		_ = json.Unmarshal(raw, &data)
+	}
+	if workspace := s.workspaces.ForURI(data.URI); workspace != nil {
+		lens = workspace.codeLensProvider.ResolveCodeLens(lens, data)
+	}
+	
+	return s.sendResponse(msg.ID, lens)
+}
+
+func (s *Server) handleFoldingRange(msg LSPMessage) error {
+	var params FoldingRangeParams
+	if err := s.unmarshalParams(msg.Params, &params); err != nil {
+		return err
+	}
+	
+	ranges := []FoldingRange{}
+	if workspace, doc := s.documentWorkspace(params.TextDocument.URI); doc != nil {
+		ranges = workspace.foldingRangeProvider.ProvideFoldingRanges(doc, s.foldingRangeClient.LineFoldingOnly, s.foldingRangeClient.RangeLimit)
+	}
+	return s.sendResponse(msg.ID, ranges)
+}
+
+func (s *Server) handleSignatureHelp(msg LSPMessage) error {
+	var params SignatureHelpParams
+	if err := s.unmarshalParams(msg.Params, &params); err != nil {
+		return err
+	}
+	
+	var help *SignatureHelp
+	if workspace, doc := s.documentWorkspace(params.TextDocument.URI); doc != nil {
+		help = workspace.signatureHelpProvider.ProvideSignatureHelp(doc, params.Position)
+	}
+	return s.sendResponse(msg.ID, help)
+}
+
+func (s *Server) handleDeclaration(msg LSPMessage) error {
+	var params DeclarationParams
+	if err := s.unmarshalParams(msg.Params, &params); err != nil {
+		return err
+	}
+	
+	links := []LocationLink{}
+	if workspace, doc := s.documentWorkspace(params.TextDocument.URI); doc != nil {
+		links = workspace.definitionProvider.ProvideDeclaration(doc, params.Position)
+	}
+	return s.sendLinks(msg.ID, links, s.hasDeclarationLinkSupport)
+}
+
+func (s *Server) handleTypeDefinition(msg LSPMessage) error {
+	var params TypeDefinitionParams
+	if err := s.unmarshalParams(msg.Params, &params); err != nil {
+		return err
+	}
+	
+	links := []LocationLink{}
+	if workspace, doc := s.documentWorkspace(params.TextDocument.URI); doc != nil {
+		links = workspace.definitionProvider.ProvideTypeDefinition(doc, params.Position)
+	}
+	return s.sendLinks(msg.ID, links, s.hasTypeDefinitionLinkSupport)
+}
+
+func (s *Server) handleImplementation(msg LSPMessage) error {
+	var params ImplementationParams
+	if err := s.unmarshalParams(msg.Params, &params); err != nil {
+		return err
+	}
+	
+	links := []LocationLink{}
+	if workspace, doc := s.documentWorkspace(params.TextDocument.URI); doc != nil {
+		links = workspace.definitionProvider.ProvideImplementation(doc, params.Position)
+	}
+	return s.sendLinks(msg.ID, links, s.hasImplementationLinkSupport)
+}
+
+func (s *Server) handlePrepareTypeHierarchy(msg LSPMessage) error {
+	var params TypeHierarchyPrepareParams
+	if err := s.unmarshalParams(msg.Params, &params); err != nil {
+		return err
+	}
+	
+	items := []TypeHierarchyItem{}
+	if workspace, doc := s.documentWorkspace(params.TextDocument.URI); doc != nil {
+		items = workspace.typeHierarchyProvider.PrepareTypeHierarchy(doc, params.Position)
+	}
+	if len(items) == 0 {
+		return s.sendResponse(msg.ID, nil)
+	}
+	return s.sendResponse(msg.ID, items)
+}
+
+func (s *Server) handleTypeHierarchySupertypes(msg LSPMessage) error {
+	var params TypeHierarchySupertypesParams
+	if err := s.unmarshalParams(msg.Params, &params); err != nil {
+		return err
+	}
+	
+	items := []TypeHierarchyItem{}
+	if workspace := s.workspaces.ForURI(params.Item.URI); workspace != nil {
+		items = workspace.typeHierarchyProvider.Supertypes(params.Item)
+	}
+	return s.sendResponse(msg.ID, items)
+}
+
+func (s *Server) handleTypeHierarchySubtypes(msg LSPMessage) error {
+	var params TypeHierarchySubtypesParams
+	if err := s.unmarshalParams(msg.Params, &params); err != nil {
+		return err
+	}
+	
+	items := []TypeHierarchyItem{}
+	if workspace := s.workspaces.ForURI(params.Item.URI); workspace != nil {
+		items = workspace.typeHierarchyProvider.Subtypes(params.Item)
+	}
+	return s.sendResponse(msg.ID, items)
+}
+
+func (s *Server) handlePrepareCallHierarchy(msg LSPMessage) error {
+	var params CallHierarchyPrepareParams
+	if err := s.unmarshalParams(msg.Params, &params); err != nil {
+		return err
+	}
+	
+	items := []CallHierarchyItem{}
+	if workspace, doc := s.documentWorkspace(params.TextDocument.URI); doc != nil {
+		items = workspace.callHierarchyProvider.PrepareCallHierarchy(doc, params.Position)
+	}
+	if len(items) == 0 {
+		return s.sendResponse(msg.ID, nil)
+	}
+	return s.sendResponse(msg.ID, items)
+}
+
+func (s *Server) handleIncomingCalls(msg LSPMessage) error {
+	var params CallHierarchyIncomingCallsParams
+	if err := s.unmarshalParams(msg.Params, &params); err != nil {
+		return err
+	}
+	
+	calls := []CallHierarchyIncomingCall{}
+	if workspace := s.workspaces.ForURI(params.Item.URI); workspace != nil {
+		calls = workspace.callHierarchyProvider.IncomingCalls(params.Item)
+	}
+	return s.sendResponse(msg.ID, calls)
+}
+
+func (s *Server) handleOutgoingCalls(msg LSPMessage) error {
+	var params CallHierarchyOutgoingCallsParams
+	if err := s.unmarshalParams(msg.Params, &params); err != nil {
+		return err
+	}
+	
+	calls := []CallHierarchyOutgoingCall{}
+	if workspace := s.workspaces.ForURI(params.Item.URI); workspace != nil {
+		calls = workspace.callHierarchyProvider.OutgoingCalls(params.Item)
+	}
+	return s.sendResponse(msg.ID, calls)
+}
+
+// documentWorkspace returns the open document with the workspace it belongs to
+func (s *Server) documentWorkspace(uri string) (*Workspace, *TextDocument) {
+	workspace := s.workspaces.ForURI(uri)
+	if workspace == nil {
+		return nil, nil
+	}
+	docInterface, ok := s.documents.Load(uri)
+	if !ok {
+		return nil, nil
+	}
+	return workspace, docInterface.(*TextDocument)
+}
+
+// sendLinks answers with LocationLinks, or plain Locations for clients
+// without link support
+func (s *Server) sendLinks(id interface{}, links []LocationLink, linkSupport bool) error {
+	if linkSupport {
+		return s.sendResponse(id, links)
+	}
+	return s.sendResponse(id, locationsFromLinks(links))
+}
+
+// locationsFromLinks converts links for clients without LocationLink support
+func locationsFromLinks(links []LocationLink) []Location {
+	locations := make([]Location, 0, len(links))
+	for _, link := range links {
+		locations = append(locations, Location{URI: link.TargetURI, Range: link.TargetSelectionRange})
+	}
+	return locations
 }
 
 func (s *Server) handleHover(msg LSPMessage) error {
@@ -1131,12 +2056,12 @@ func (s *Server) handleHover(msg LSPMessage) error {
 	
 	var hover *Hover
 	
-	if s.hoverProvider != nil {
+	if workspace := s.workspaces.ForURI(params.TextDocument.URI); workspace != nil {
 		docInterface, ok := s.documents.Load(params.TextDocument.URI)
 		if ok {
 			doc := docInterface.(*TextDocument)
 			var err error
-			hover, err = s.hoverProvider.ProvideHover(doc, params.Position)
+			hover, err = workspace.hoverProvider.ProvideHover(doc, params.Position)
 			if err != nil {
 				log.Printf("[view.tree] Error providing hover: %v", err)
 			}
@@ -1146,17 +2071,37 @@ func (s *Server) handleHover(msg LSPMessage) error {
 	return s.sendResponse(msg.ID, hover)
 }
 
+func (s *Server) handleCodeAction(msg LSPMessage) error {
+	var params CodeActionParams
+	if err := s.unmarshalParams(msg.Params, &params); err != nil {
+		return err
+	}
+	
+	actions := []CodeAction{}
+	
+	if workspace := s.workspaces.ForURI(params.TextDocument.URI); workspace != nil {
+		docInterface, ok := s.documents.Load(params.TextDocument.URI)
+		if ok {
+			doc := docInterface.(*TextDocument)
+			actions = workspace.codeActionProvider.ProvideCodeActions(doc, params)
+		}
+	}
+	
+	return s.sendResponse(msg.ID, actions)
+}
+
 func (s *Server) handleShutdown(msg LSPMessage) error {
 	log.Println("[view.tree] Shutting down...")
 	return s.sendResponse(msg.ID, nil)
 }
 
 func (s *Server) validateTextDocument(doc *TextDocument) {
-	if s.diagnosticProvider == nil || !strings.HasSuffix(doc.URI, ".view.tree") {
+	workspace := s.workspaces.ForURI(doc.URI)
+	if workspace == nil || !strings.HasSuffix(doc.URI, ".view.tree") {
 		return
 	}
 	
-	diagnostics, err := s.diagnosticProvider.ProvideDiagnostics(doc)
+	diagnostics, err := workspace.diagnosticProvider.ProvideDiagnostics(doc)
 	if err != nil {
 		log.Printf("[view.tree] Error validating document: %v", err)
 		return
@@ -1199,12 +2144,28 @@ func (s *Server) positionToOffset(lines []string, pos Position) int {
 }
 
 func (s *Server) uriToFilePath(uri string) string {
-	// Simple URI to file path conversion
-	// In a real implementation, this would be more robust
-	if strings.HasPrefix(uri, "file://") {
-		return strings.TrimPrefix(uri, "file://")
+	return uriToPath(uri)
+}
+
+// pathToURI converts a file path to a file URI, escaping characters such as
+// spaces. URIs are returned unchanged.
+func pathToURI(filePath string) string {
+	if strings.HasPrefix(filePath, "file://") {
+		return filePath
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(filePath)}).String()
+}
+
+// uriToPath converts a file URI to a path, decoding escapes such as "%20"
+func uriToPath(uri string) string {
+	if !strings.HasPrefix(uri, "file://") {
+		return uri
+	}
+	filePath := strings.TrimPrefix(uri, "file://")
+	if unescaped, err := url.PathUnescape(filePath); err == nil {
+		return unescaped
 	}
-	return uri
+	return filePath
 }
 
 func (s *Server) unmarshalParams(params interface{}, target interface{}) error {
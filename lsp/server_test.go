@@ -1,11 +1,29 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
 
+// writeTestFiles creates files relative to root, making parent directories as needed
+func writeTestFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 func TestNewServer(t *testing.T) {
 	server := NewServer()
 	if server == nil {
@@ -99,6 +117,32 @@ func TestApplyTextChange(t *testing.T) {
 	}
 }
 
+func TestDidChangeReplacesDocument(t *testing.T) {
+	server := NewServer()
+	var output bytes.Buffer
+	server.writer = &output
+	
+	open := `{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///test/app.view.tree","languageId":"view.tree","version":1,"text":"$my_app $mol_view"}}}`
+	if err := server.handleMessage([]byte(open)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	opened, _ := server.documents.Load("file:///test/app.view.tree")
+	
+	// Background validation may still read the previous document
+	change := `{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"file:///test/app.view.tree","version":2},"contentChanges":[{"text":"$my_app $mol_page"}]}}`
+	if err := server.handleMessage([]byte(change)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	changed, _ := server.documents.Load("file:///test/app.view.tree")
+	
+	if previous := opened.(*TextDocument); previous.Text != "$my_app $mol_view" || previous.Version != 1 {
+		t.Errorf("Expected the opened document to stay unchanged, got %+v", previous)
+	}
+	if current := changed.(*TextDocument); current.Text != "$my_app $mol_page" || current.Version != 2 || current.LanguageID != "view.tree" {
+		t.Errorf("Expected the changed document to be stored, got %+v", current)
+	}
+}
+
 func TestURIConversion(t *testing.T) {
 	server := NewServer()
 	
@@ -639,3 +683,1903 @@ func TestHoverProvider(t *testing.T) {
 		})
 	}
 }
+
+func TestCommentNodes(t *testing.T) {
+	parser := NewViewTreeParser()
+	
+	content := `- header comment
+$my_component $mol_view
+	- disabled property
+		nested_in_comment value
+	title \Hello
+	numbs /number
+		-Infinity`
+	
+	result := parser.Parse(content)
+	
+	if len(result.Comments) != 2 {
+		t.Fatalf("Expected 2 comments, got %d", len(result.Comments))
+	}
+	
+	if result.Comments[1].Text != "disabled property" {
+		t.Errorf("Expected comment text 'disabled property', got '%s'", result.Comments[1].Text)
+	}
+	
+	for _, property := range result.Components[0].Properties {
+		if property.Name == "nested_in_comment" {
+			t.Error("Property nested under a comment should not be parsed")
+		}
+	}
+	
+	commentLines := parser.CommentLines(content)
+	if !commentLines[0] || !commentLines[2] || !commentLines[3] {
+		t.Errorf("Expected lines 0, 2 and 3 to be comment lines, got %v", commentLines)
+	}
+	if commentLines[6] {
+		t.Error("-Infinity value should not be treated as a comment")
+	}
+}
+
+func TestDiagnosticSuppression(t *testing.T) {
+	scanner := NewProjectScanner(".")
+	provider := NewDiagnosticProvider(scanner)
+	
+	countCode := func(diagnostics []Diagnostic, code string) int {
+		count := 0
+		for _, diag := range diagnostics {
+			if diag.Code == code {
+				count++
+			}
+		}
+		return count
+	}
+	
+	document := &TextDocument{
+		URI: "file:///test.view.tree",
+		Text: `$my_component $mol_view
+	title \One
+	- vt-disable-next-line VT004
+	title \Two
+	hint \One
+	- vt-disable-next-line VT004
+	hint \Two
+	hint \Three`,
+	}
+	
+	diagnostics, err := provider.ProvideDiagnostics(document)
+	if err != nil {
+		t.Fatalf("ProvideDiagnostics failed: %v", err)
+	}
+	
+	// Only the second duplicate of hint is left unsuppressed
+	if count := countCode(diagnostics, RuleDuplicateProperty); count != 1 {
+		t.Errorf("Expected 1 duplicate property diagnostic, got %d: %v", count, diagnostics)
+	}
+	if count := countCode(diagnostics, RuleUnusedSuppression); count != 0 {
+		t.Errorf("Expected no unused suppressions, got %d", count)
+	}
+	
+	// File-level suppression and unused suppression reporting
+	document.Text = `- vt-disable VT004 VT003
+$my_component $mol_view
+	title \One
+	title \Two`
+	
+	diagnostics, err = provider.ProvideDiagnostics(document)
+	if err != nil {
+		t.Fatalf("ProvideDiagnostics failed: %v", err)
+	}
+	
+	if count := countCode(diagnostics, RuleDuplicateProperty); count != 0 {
+		t.Errorf("Expected duplicate properties to be suppressed, got %d", count)
+	}
+	
+	unused := 0
+	for _, diag := range diagnostics {
+		if diag.Code == RuleUnusedSuppression {
+			unused++
+			if !strings.Contains(diag.Message, "VT003") || strings.Contains(diag.Message, "VT004") {
+				t.Errorf("Unexpected unused suppression message: %s", diag.Message)
+			}
+		}
+	}
+	if unused != 1 {
+		t.Errorf("Expected 1 unused suppression diagnostic, got %d", unused)
+	}
+}
+
+func TestParseSettings(t *testing.T) {
+	settings, err := ParseSettings(map[string]interface{}{
+		"scanRoots":  []interface{}{"../lib"},
+		"maxTsFiles": 500,
+		"diagnostics": map[string]interface{}{
+			"severity": map[string]interface{}{
+				"VT002": "off",
+				"VT004": "hint",
+			},
+		},
+		"hover": map[string]interface{}{
+			"verbosity": "bogus",
+		},
+	})
+	if err != nil {
+		t.Fatalf("ParseSettings failed: %v", err)
+	}
+	
+	if len(settings.ScanRoots) != 1 || settings.ScanRoots[0] != "../lib" {
+		t.Errorf("Expected scan roots [../lib], got %v", settings.ScanRoots)
+	}
+	if settings.MaxTsFiles != 500 {
+		t.Errorf("Expected maxTsFiles 500, got %d", settings.MaxTsFiles)
+	}
+	if settings.Hover.Verbosity != HoverVerbosityNormal {
+		t.Errorf("Expected unknown verbosity to fall back to normal, got '%s'", settings.Hover.Verbosity)
+	}
+	if settings.Completion.SnippetStyle != SnippetStyleSnippet {
+		t.Errorf("Expected default snippet style, got '%s'", settings.Completion.SnippetStyle)
+	}
+	if !settings.IsRuleDisabled("VT002") {
+		t.Error("Expected VT002 to be disabled")
+	}
+	if severity, ok := settings.RuleSeverity("VT004"); !ok || severity != DiagnosticSeverityHint {
+		t.Errorf("Expected VT004 severity hint, got %d", severity)
+	}
+	
+	defaults, err := ParseSettings(nil)
+	if err != nil {
+		t.Fatalf("ParseSettings(nil) failed: %v", err)
+	}
+	if !defaults.ScanSettingsEqual(DefaultSettings()) {
+		t.Error("Expected nil settings to equal defaults")
+	}
+}
+
+func TestLoadSettingsFile(t *testing.T) {
+	root := t.TempDir()
+	
+	settings, err := LoadSettingsFile(root)
+	if err != nil {
+		t.Fatalf("LoadSettingsFile failed without file: %v", err)
+	}
+	if settings.MaxTsFiles != DefaultSettings().MaxTsFiles {
+		t.Errorf("Expected defaults without %s", SettingsFileName)
+	}
+	
+	writeTestFiles(t, root, map[string]string{
+		SettingsFileName: `{"exclude": ["-*"], "completion": {"snippetStyle": "plain"}}`,
+	})
+	
+	settings, err = LoadSettingsFile(root)
+	if err != nil {
+		t.Fatalf("LoadSettingsFile failed: %v", err)
+	}
+	if len(settings.Exclude) != 1 || settings.Exclude[0] != "-*" {
+		t.Errorf("Expected exclude [-*], got %v", settings.Exclude)
+	}
+	if settings.Completion.SnippetStyle != SnippetStylePlain {
+		t.Errorf("Expected plain snippet style, got '%s'", settings.Completion.SnippetStyle)
+	}
+}
+
+func TestScanWithSettings(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"ws/app/app.view.tree":        "$my_app $mol_view\n\ttitle \\App",
+		"ws/-view.tree/app.view.tree": "$my_generated $mol_view",
+		"lib/lib.view.tree":           "$my_lib $mol_view",
+	}
+	writeTestFiles(t, root, files)
+	
+	scanner := NewProjectScanner(filepath.Join(root, "ws"))
+	settings := DefaultSettings()
+	settings.Exclude = []string{"-*"}
+	settings.ScanRoots = []string{"../lib"}
+	scanner.SetSettings(settings)
+	
+	if err := scanner.ScanProject(); err != nil {
+		t.Fatalf("ScanProject failed: %v", err)
+	}
+	
+	if !scanner.HasComponent("$my_app") {
+		t.Error("Expected $my_app to be indexed")
+	}
+	if !scanner.HasComponent("$my_lib") {
+		t.Error("Expected $my_lib from the extra scan root to be indexed")
+	}
+	if scanner.HasComponent("$my_generated") {
+		t.Error("Expected excluded directory to be skipped")
+	}
+}
+
+func TestRescanKeepsIndexReadable(t *testing.T) {
+	root := t.TempDir()
+	files := make(map[string]string)
+	for i := 0; i < 50; i++ {
+		files[fmt.Sprintf("my/c%02d/c%02d.view.tree", i, i)] = fmt.Sprintf("$my_c%02d $mol_view\n\ttitle \\", i)
+	}
+	writeTestFiles(t, root, files)
+	
+	scanner := NewProjectScanner(root)
+	if err := scanner.ScanProject(); err != nil {
+		t.Fatal(err)
+	}
+	
+	// Readers keep the previous index until the rescan swaps in the new one
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 5; i++ {
+			if err := scanner.ScanProject(); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		if count := len(scanner.GetComponents()); count != 50 {
+			t.Fatalf("Expected 50 components during a rescan, got %d", count)
+		}
+		scanner.GetComponentDecls()
+	}
+}
+
+func TestRuleSeverityOverrides(t *testing.T) {
+	scanner := NewProjectScanner(".")
+	settings := DefaultSettings()
+	settings.Diagnostics.Severity = map[string]string{
+		RuleDuplicateProperty: "error",
+		RuleIndentation:       RuleSeverityOff,
+	}
+	scanner.SetSettings(settings)
+	provider := NewDiagnosticProvider(scanner)
+	
+	document := &TextDocument{
+		URI:  "file:///test.view.tree",
+		Text: "$my_component $mol_view\n\ttitle \\One\n\ttitle \\Two\n\t\t\tdeep \\Value",
+	}
+	
+	diagnostics, err := provider.ProvideDiagnostics(document)
+	if err != nil {
+		t.Fatalf("ProvideDiagnostics failed: %v", err)
+	}
+	
+	foundDuplicate := false
+	for _, diag := range diagnostics {
+		if diag.Code == RuleIndentation {
+			t.Errorf("Expected indentation rule to be disabled: %s", diag.Message)
+		}
+		if diag.Code == RuleDuplicateProperty {
+			foundDuplicate = true
+			if diag.Severity != DiagnosticSeverityError {
+				t.Errorf("Expected duplicate property severity error, got %d", diag.Severity)
+			}
+		}
+	}
+	if !foundDuplicate {
+		t.Error("Expected a duplicate property diagnostic")
+	}
+}
+
+func TestWorkspaceConfigurationRequest(t *testing.T) {
+	server := NewServer()
+	var output bytes.Buffer
+	server.writer = &output
+	server.hasConfigurationCapability = true
+	workspace := NewWorkspace(WorkspaceFolder{Name: "test"}, t.TempDir())
+	server.workspaces.Add(workspace)
+	
+	server.loadSettings()
+	
+	if !strings.Contains(output.String(), `"method":"workspace/configuration"`) {
+		t.Fatalf("Expected a workspace/configuration request, got: %s", output.String())
+	}
+	
+	response := `{"jsonrpc":"2.0","id":1,"result":[{"hover":{"verbosity":"verbose"}}]}`
+	if err := server.handleMessage([]byte(response)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	
+	if workspace.projectScanner.Settings().Hover.Verbosity != HoverVerbosityVerbose {
+		t.Errorf("Expected verbose hover after configuration response, got '%s'", workspace.projectScanner.Settings().Hover.Verbosity)
+	}
+	
+	if len(server.pendingRequests) != 0 {
+		t.Errorf("Expected no pending requests, got %d", len(server.pendingRequests))
+	}
+}
+
+func TestSettingsFilePerFolder(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"app/" + SettingsFileName: `{"maxTsFiles": 10}`,
+		"lib/" + SettingsFileName: `{"completion": {"snippetStyle": "plain"}}`,
+	})
+	
+	server := NewServer()
+	var output bytes.Buffer
+	server.writer = &output
+	app := server.addWorkspaceFolder(WorkspaceFolder{URI: pathToURI(filepath.Join(root, "app")), Name: "app"})
+	server.loadSettings()
+	lib := server.addWorkspaceFolder(WorkspaceFolder{URI: pathToURI(filepath.Join(root, "lib")), Name: "lib"})
+	
+	if maxTsFiles := app.projectScanner.Settings().MaxTsFiles; maxTsFiles != 10 {
+		t.Errorf("Expected the app folder to read its own file, got maxTsFiles %d", maxTsFiles)
+	}
+	if settings := lib.projectScanner.Settings(); settings.MaxTsFiles != DefaultSettings().MaxTsFiles || settings.Completion.SnippetStyle != SnippetStylePlain {
+		t.Errorf("Expected an added folder to read its own file, got maxTsFiles %d and snippet style '%s'", settings.MaxTsFiles, settings.Completion.SnippetStyle)
+	}
+	
+	// Settings pushed by the client apply to every folder
+	pushed := DefaultSettings()
+	pushed.MaxTsFiles = 20
+	server.applySettings(pushed)
+	other := server.addWorkspaceFolder(WorkspaceFolder{URI: pathToURI(filepath.Join(root, "other")), Name: "other"})
+	for _, workspace := range []*Workspace{app, lib, other} {
+		if maxTsFiles := workspace.projectScanner.Settings().MaxTsFiles; maxTsFiles != 20 {
+			t.Errorf("Expected the client settings in %s, got maxTsFiles %d", workspace.Root, maxTsFiles)
+		}
+	}
+}
+
+func TestWorkspaceManagerRouting(t *testing.T) {
+	manager := NewWorkspaceManager()
+	outer := NewWorkspace(WorkspaceFolder{URI: "file:///ws", Name: "ws"}, "/ws")
+	inner := NewWorkspace(WorkspaceFolder{URI: "file:///ws/hyoo/app", Name: "app"}, "/ws/hyoo/app")
+	other := NewWorkspace(WorkspaceFolder{URI: "file:///other", Name: "other"}, "/other")
+	spaced := NewWorkspace(WorkspaceFolder{URI: "file:///my%20projects/app", Name: "app"}, "/my projects/app")
+	manager.Add(outer)
+	manager.Add(inner)
+	manager.Add(other)
+	manager.Add(spaced)
+	if manager.Add(NewWorkspace(WorkspaceFolder{URI: "file:///other", Name: "other"}, "/other")) {
+		t.Error("Expected a second workspace with the same root to be rejected")
+	}
+	
+	testCases := []struct {
+		uri      string
+		expected *Workspace
+	}{
+		{"file:///ws/hyoo/app/app.view.tree", inner},
+		{"file:///ws/hyoo/lib/lib.view.tree", outer},
+		{"file:///other/x.view.tree", other},
+		{"file:///my%20projects/app/app.view.tree", spaced},
+		{"file:///ws2/x.view.tree", outer}, // outside every root: first workspace
+	}
+	
+	for _, tc := range testCases {
+		if workspace := manager.ForURI(tc.uri); workspace != tc.expected {
+			t.Errorf("Expected %s to route to %s, got %s", tc.uri, tc.expected.Root, workspace.Root)
+		}
+	}
+	
+	if manager.Remove("/ws/hyoo/app") != inner {
+		t.Error("Expected Remove to return the removed workspace")
+	}
+	if workspace := manager.ForURI("file:///ws/hyoo/app/app.view.tree"); workspace != outer {
+		t.Errorf("Expected removed folder to fall back to outer root, got %s", workspace.Root)
+	}
+}
+
+func TestAddWorkspaceFolder(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{"hyoo/app/app.view.tree": "$hyoo_app $mol_view"}
+	for i := 0; i < 30; i++ {
+		files[fmt.Sprintf("hyoo/lib/c%02d/c%02d.view.tree", i, i)] = fmt.Sprintf("$hyoo_lib_c%02d $mol_view", i)
+	}
+	writeTestFiles(t, root, files)
+	
+	server := NewServer()
+	var output bytes.Buffer
+	server.writer = &output
+	app := server.addWorkspaceFolder(WorkspaceFolder{URI: pathToURI(filepath.Join(root, "hyoo", "app")), Name: "app"})
+	if app == nil {
+		t.Fatal("Expected the app folder to be added")
+	}
+	
+	// Peers only see the new folder once it is fully scanned
+	libFolder := WorkspaceFolder{URI: pathToURI(filepath.Join(root, "hyoo", "lib")), Name: "lib"}
+	done := make(chan *Workspace)
+	go func() {
+		done <- server.addWorkspaceFolder(libFolder)
+	}()
+	var lib *Workspace
+	for lib == nil {
+		select {
+		case lib = <-done:
+		default:
+		}
+		registered := server.workspaces.Has(filepath.Join(root, "hyoo", "lib"))
+		if count := len(app.projectScanner.GetComponents()); registered && count != 31 {
+			t.Fatalf("Expected a registered peer to be fully scanned, got %d components", count)
+		}
+	}
+	if count := len(app.projectScanner.GetComponents()); count != 31 {
+		t.Errorf("Expected the peer components after adding the folder, got %d", count)
+	}
+	
+	if server.addWorkspaceFolder(libFolder) != nil {
+		t.Error("Expected an already registered folder to be skipped")
+	}
+}
+
+func TestCrossRootComponentResolution(t *testing.T) {
+	manager := NewWorkspaceManager()
+	app := NewWorkspace(WorkspaceFolder{Name: "app"}, "/ws/hyoo/app")
+	lib := NewWorkspace(WorkspaceFolder{Name: "lib"}, "/ws/hyoo/lib")
+	manager.Add(app)
+	manager.Add(lib)
+	
+	app.projectScanner.parseViewTreeFile("$hyoo_app $mol_view\n\tsub /\n\t\t<= Button $hyoo_lib_button", "/ws/hyoo/app/app.view.tree")
+	lib.projectScanner.parseViewTreeFile("$hyoo_lib_button $mol_button\n\tcaption \\Click", "/ws/hyoo/lib/button.view.tree")
+	lib.projectScanner.parseTsFile("const x = $acme_widget", "/ws/hyoo/lib/button.ts")
+	
+	if !app.projectScanner.HasComponent("$hyoo_lib_button") {
+		t.Error("Expected $hyoo_lib_button to resolve from the peer root sharing the hyoo namespace")
+	}
+	if file := app.projectScanner.GetComponentFile("$hyoo_lib_button"); file != "/ws/hyoo/lib/button.view.tree" {
+		t.Errorf("Expected component file from peer root, got '%s'", file)
+	}
+	if properties := app.projectScanner.GetPropertiesForComponent("$hyoo_lib_button"); len(properties) != 1 || properties[0] != "caption" {
+		t.Errorf("Expected [caption] from peer root, got %v", properties)
+	}
+	if app.projectScanner.HasComponent("$acme_widget") {
+		t.Error("Expected components outside the peer's declared namespaces not to resolve")
+	}
+	
+	found := false
+	for _, component := range app.projectScanner.GetComponents() {
+		if component == "$hyoo_lib_button" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected GetComponents to include peer components")
+	}
+	
+	manager.Remove("/ws/hyoo/lib")
+	if app.projectScanner.HasComponent("$hyoo_lib_button") {
+		t.Error("Expected peer components to disappear after the folder is removed")
+	}
+}
+
+func TestLibraryIndexing(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"mol/view/view.view.tree":                 "$mol_view $mol_object\n\tsub /",
+		"mol/button/button.view.tree":             "$mol_button $mol_view\n\tcaption \\Click",
+		"mol/button/button.d.ts":                  "declare namespace $ {\n\tclass $mol_button_minor extends $mol_button {}\n\tlet x: $mol_unrelated\n}",
+		"hyoo/app/app.view.tree":                  "$hyoo_app $mol_view\n\tsub /\n\t\t<= Button $mol_button",
+		"hyoo/app/node_modules/mol_x/x.view.tree": "$mol_x $mol_view",
+		"hyoo/app/view.view.tree":                 "$mol_view $mol_object\n\ttitle \\Local",
+	}
+	writeTestFiles(t, root, files)
+	
+	workspaceRoot := filepath.Join(root, "hyoo", "app")
+	detected := detectLibraryRoots(workspaceRoot)
+	if len(detected) != 2 || detected[0] != filepath.Join(root, "mol") || detected[1] != filepath.Join(workspaceRoot, "node_modules", "mol_x") {
+		t.Fatalf("Expected MAM mol root and node_modules/mol_x, got %v", detected)
+	}
+	
+	scanner := NewProjectScanner(workspaceRoot)
+	if err := scanner.ScanProject(); err != nil {
+		t.Fatalf("ScanProject failed: %v", err)
+	}
+	if scanner.HasComponent("$mol_button") {
+		t.Error("Expected library detection to be opt-in")
+	}
+	
+	settings := DefaultSettings()
+	settings.DetectLibraries = true
+	scanner.SetSettings(settings)
+	if err := scanner.ScanProject(); err != nil {
+		t.Fatalf("ScanProject failed: %v", err)
+	}
+	
+	if !scanner.HasComponent("$mol_button") || !scanner.IsLibraryComponent("$mol_button") {
+		t.Error("Expected $mol_button to resolve from the library")
+	}
+	if properties := scanner.GetPropertiesForComponent("$mol_button"); len(properties) != 1 || properties[0] != "caption" {
+		t.Errorf("Expected [caption] from the library, got %v", properties)
+	}
+	if !scanner.HasComponent("$mol_button_minor") {
+		t.Error("Expected classes declared in library .d.ts files to be indexed")
+	}
+	if scanner.HasComponent("$mol_unrelated") {
+		t.Error("Expected type references in library .ts files not to be indexed")
+	}
+	if !scanner.HasComponent("$mol_x") {
+		t.Error("Expected node_modules/mol_* packages to be indexed as libraries")
+	}
+	
+	// Workspace sources take precedence over the library
+	if scanner.IsLibraryComponent("$mol_view") {
+		t.Error("Expected the workspace definition of $mol_view to win")
+	}
+	if file := scanner.GetComponentFile("$mol_view"); file != filepath.Join(workspaceRoot, "view.view.tree") {
+		t.Errorf("Expected workspace file for $mol_view, got '%s'", file)
+	}
+	
+	// Library files are read-only and ignored by incremental updates
+	libraryFile := filepath.Join(root, "mol", "button", "button.view.tree")
+	scanner.UpdateSingleFile(libraryFile, "$mol_button $mol_view\n\tcaption \\Click")
+	if !scanner.IsLibraryComponent("$mol_button") {
+		t.Error("Expected library file updates not to move components into the workspace index")
+	}
+	
+	completionProvider := NewCompletionProvider(scanner)
+	var items []CompletionItem
+	completionProvider.addComponentCompletions(&items)
+	for _, item := range items {
+		if item.Label == "$mol_button" && item.SortText != "2$mol_button" {
+			t.Errorf("Expected library components to sort after workspace ones, got '%s'", item.SortText)
+		}
+		if item.Label == "$hyoo_app" && item.SortText != "1$hyoo_app" {
+			t.Errorf("Expected workspace components to keep priority, got '%s'", item.SortText)
+		}
+	}
+	
+	settings.MaxLibraryFiles = 1
+	scanner.SetSettings(settings)
+	if err := scanner.ScanProject(); err != nil {
+		t.Fatalf("ScanProject failed: %v", err)
+	}
+	indexed := 0
+	for _, component := range []string{"$mol_button", "$mol_button_minor", "$mol_view", "$mol_x"} {
+		if scanner.IsLibraryComponent(component) {
+			indexed++
+		}
+	}
+	if indexed != 1 {
+		t.Errorf("Expected the library walk to stop after one file, got %d library components", indexed)
+	}
+}
+
+func TestIgnoreRules(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":                   "# packs and build outputs\n/*/\n!/app/\n*.gen.view.tree\n",
+		"mam.meta.tree":                "pack mol git \\https://github.com/hyoo-ru/mam_mol.git\n",
+		"app/.ignore":                  "tmp/\n",
+		"app/app.view.tree":            "$my_app $mol_view\n\ttitle \\App",
+		"app/-view.tree/app.view.tree": "$my_app $mol_page\n\tgenerated \\Yes",
+		"app/app.gen.view.tree":        "$my_gen $mol_view",
+		"app/tmp/tmp.view.tree":        "$my_tmp $mol_view",
+		"other/other.view.tree":        "$my_other $mol_view",
+		"mol/button.view.tree":         "$mol_button $mol_view",
+	}
+	writeTestFiles(t, root, files)
+	
+	scanner := NewProjectScanner(root)
+	if err := scanner.ScanProject(); err != nil {
+		t.Fatalf("ScanProject failed: %v", err)
+	}
+	
+	if properties := scanner.GetPropertiesForComponent("$my_app"); len(properties) != 1 || properties[0] != "title" {
+		t.Errorf("Expected build outputs not to shadow $my_app, got %v", properties)
+	}
+	for _, component := range []string{"$my_gen", "$my_tmp", "$my_other"} {
+		if scanner.HasComponent(component) {
+			t.Errorf("Expected ignored component %s not to be indexed", component)
+		}
+	}
+	if !scanner.HasComponent("$mol_button") {
+		t.Error("Expected meta.tree pack directories to be scanned despite .gitignore")
+	}
+	
+	scanner.UpdateSingleFile(filepath.Join(root, "app", "-view.tree", "app.view.tree"), files["app/-view.tree/app.view.tree"])
+	if file := scanner.GetComponentFile("$my_app"); file != filepath.Join(root, "app", "app.view.tree") {
+		t.Errorf("Expected updates of build outputs to be ignored, got '%s'", file)
+	}
+	
+	// Changed ignore files apply to later updates without a rescan
+	tmpFile := filepath.Join(root, "app", "tmp", "tmp.view.tree")
+	scanner.UpdateSingleFile(tmpFile, files["app/tmp/tmp.view.tree"])
+	if scanner.HasComponent("$my_tmp") {
+		t.Error("Expected updates of ignored files to be skipped")
+	}
+	ignoreFile := filepath.Join(root, "app", ".ignore")
+	if err := os.WriteFile(ignoreFile, []byte("cache/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	scanner.UpdateSingleFile(ignoreFile, "cache/\n")
+	scanner.UpdateSingleFile(tmpFile, files["app/tmp/tmp.view.tree"])
+	if !scanner.HasComponent("$my_tmp") {
+		t.Error("Expected the ignore rules to be reloaded after .ignore changed")
+	}
+	
+	settings := DefaultSettings()
+	settings.RespectIgnoreFiles = false
+	scanner.SetSettings(settings)
+	if err := scanner.ScanProject(); err != nil {
+		t.Fatalf("ScanProject failed: %v", err)
+	}
+	if !scanner.HasComponent("$my_other") {
+		t.Error("Expected ignore files to be skipped when respectIgnoreFiles is off")
+	}
+}
+
+func TestParseTree(t *testing.T) {
+	content := "$my_app $mol_page\n\ttitle @ \\Hello world\n\t- comment\n\t\tignored\n\tsub /\n\t\t<= Button $mol_button\n\t\t\tclick? <=> go? null"
+	tree := ParseTree(content)
+	
+	if len(tree.Children) != 1 || tree.Children[0].Name != "$my_app" {
+		t.Fatalf("Expected single root $my_app, got %d roots", len(tree.Children))
+	}
+	base := tree.Children[0].First()
+	if base == nil || base.Name != "$mol_page" || len(base.Children) != 2 {
+		t.Fatalf("Expected $mol_page with 2 properties outside the comment, got %+v", base)
+	}
+	
+	title := base.Children[0]
+	data := title.First().First()
+	if title.Name != "title" || !data.IsData() || data.Data != "Hello world" {
+		t.Errorf("Expected localized data node, got %+v", data)
+	}
+	
+	node := tree.NodeAt(Position{Line: 6, Character: 14})
+	if node == nil || node.Name != "go?" || node.Parent.Name != "<=>" {
+		t.Errorf("Expected go? under <=> at cursor, got %+v", node)
+	}
+	
+	decls := BuildComponentDecls(tree, "app.view.tree")
+	if len(decls) != 1 || decls[0].Base != "$mol_page" {
+		t.Fatalf("Expected $my_app extending $mol_page, got %+v", decls)
+	}
+	decl := decls[0]
+	for _, name := range []string{"title", "sub", "Button", "go"} {
+		if decl.Property(name) == nil {
+			t.Errorf("Expected property %s to be declared", name)
+		}
+	}
+	if decl.Property("go").Via != BindingTwoWay || !decl.Property("go").Mutable {
+		t.Errorf("Expected go? to be a mutable property introduced by <=>, got %+v", decl.Property("go"))
+	}
+	if len(decl.Instances) != 1 || decl.Instances[0].Component != "$mol_button" || decl.Instances[0].Property.Name != "Button" {
+		t.Errorf("Expected $mol_button instance created by Button, got %+v", decl.Instances)
+	}
+	if len(decl.Bindings) != 2 || decl.Bindings[1].Target != "click" || decl.Bindings[1].Instance == nil {
+		t.Errorf("Expected click override bound to go, got %+v", decl.Bindings)
+	}
+	
+	rootless := BuildComponentDecls(ParseTree("$my_root\n\ttitle \\x"), "root.view.tree")
+	if len(rootless) != 1 || rootless[0].Base != "" || rootless[0].Property("title") == nil {
+		t.Errorf("Expected a component without base to keep its properties, got %+v", rootless)
+	}
+}
+
+func TestParseTsClasses(t *testing.T) {
+	content := `namespace $.$$ {
+	/**
+	 * Application behaviour
+	 */
+	export class $my_app extends $.$my_app {
+		
+		/** Number of rows */
+		@ $mol_mem
+		count(): number {
+			if (this.ready()) { return this.rows().length }
+			return 0
+		}
+		
+		title() {
+			return "{" + this.name()
+		}
+	}
+}`
+	classes := ParseTsClasses(content, "app.view.ts")
+	if len(classes) != 1 {
+		t.Fatalf("Expected 1 class, got %d", len(classes))
+	}
+	class := classes[0]
+	if class.Name != "$my_app" || class.Base != "$.$my_app" || class.Doc != "Application behaviour" {
+		t.Errorf("Unexpected class %+v", class)
+	}
+	count := class.Methods["count"]
+	if count == nil || count.ReturnType != "number" || count.Doc != "Number of rows" {
+		t.Fatalf("Expected count(): number with doc, got %+v", count)
+	}
+	if strings.Join(count.Calls, ",") != "ready,rows" {
+		t.Errorf("Expected calls ready,rows, got %v", count.Calls)
+	}
+	if title := class.Methods["title"]; title == nil || title.ReturnType != "" || strings.Join(title.Calls, ",") != "name" {
+		t.Errorf("Expected title without annotation calling name, got %+v", title)
+	}
+	if class.Methods["if"] != nil {
+		t.Error("Expected control flow not to be parsed as methods")
+	}
+}
+
+func TestTypeInference(t *testing.T) {
+	scanner := NewProjectScanner("/test")
+	scanner.parseViewTreeFile(`$my_base $mol_view
+	enabled true
+	size 10
+$my_app $my_base
+	title @ \Hello
+	limit +Infinity
+	ratio NaN
+	empty null
+	rows /number
+	items /
+		\a
+		\b
+	views /$mol_view
+	attr *
+		id \main
+	Button $mol_button
+		caption => button_caption
+	heading <= title
+	value? <=> current? 5
+	enabled ^
+	count 0`, "/test/app.view.tree")
+	scanner.parseViewTreeFile("$mol_button $mol_view\n\tcaption \\", "/test/button.view.tree")
+	scanner.parseTsFile("namespace $.$$ {\n\texport class $my_app extends $.$my_app {\n\t\tcount(): string[] {\n\t\t\treturn []\n\t\t}\n\t}\n}", "/test/app.view.ts")
+	
+	inferrer := NewTypeInferrer(scanner)
+	expected := map[string]string{
+		"title":          "string",
+		"limit":          "number",
+		"ratio":          "number",
+		"empty":          "null",
+		"rows":           "Array<number>",
+		"items":          "Array<string>",
+		"views":          "Array<$mol_view>",
+		"attr":           "Dictionary<string>",
+		"Button":         "$mol_button",
+		"button_caption": "string",
+		"heading":        "string",
+		"value":          "number",
+		"current":        "number",
+		"enabled":        "boolean",
+		"size":           "number",
+		"count":          "Array<string>",
+		"missing":        "unknown",
+	}
+	for property, want := range expected {
+		if got := inferrer.PropertyType("$my_app", property).String(); got != want {
+			t.Errorf("Expected %s to be %s, got %s", property, want, got)
+		}
+	}
+	
+	button := &ValueType{Kind: TypeComponent, Component: "$mol_button"}
+	view := &ValueType{Kind: TypeComponent, Component: "$mol_view"}
+	if !inferrer.IsAssignable(button, view) {
+		t.Error("Expected $mol_button to be assignable to $mol_view")
+	}
+	if !inferrer.IsAssignable(&ValueType{Kind: TypeNull}, &ValueType{Kind: TypeBoolean}) {
+		t.Error("Expected null to be assignable to boolean")
+	}
+	if inferrer.IsAssignable(&ValueType{Kind: TypeString}, &ValueType{Kind: TypeBoolean}) {
+		t.Error("Expected string not to be assignable to boolean")
+	}
+	if inferrer.IsClassChainComplete("") {
+		t.Error("Expected an empty class chain to be incomplete")
+	}
+	
+	content := "$my_page $my_app\n\trows /number\n\tButton $mol_button\n\t\tcaption \\Go"
+	hover, err := NewHoverProvider(scanner).ProvideHover(&TextDocument{URI: "file:///test/page.view.tree", Text: content}, Position{Line: 1, Character: 2})
+	if err != nil || hover == nil || !strings.Contains(hover.Contents.Value, "**Type**: `Array<number>`") {
+		t.Errorf("Expected hover to show the inferred type, got %+v", hover)
+	}
+}
+
+func TestPropertyHoverDetails(t *testing.T) {
+	scanner := NewProjectScanner("/test")
+	scanner.parseViewTreeFile("$my_base $mol_view\n\ttitle \\", "/test/base.view.tree")
+	scanner.parseTsFile("namespace $.$$ {\n\texport class $my_page extends $.$my_page {\n\t\t/**\n\t\t * Page heading\n\t\t */\n\t\ttitle(): string {\n\t\t\treturn \"\"\n\t\t}\n\t}\n}", "/test/page.view.ts")
+	provider := NewHoverProvider(scanner)
+	
+	content := `$my_page $my_base
+	title <= caption \Hello
+	Head $mol_view
+		sub /
+			<= caption
+	Button $mol_button
+		title <= caption
+$my_page_sub $my_page
+	hint <= caption
+$my_other $mol_view
+	label <= caption`
+	document := &TextDocument{URI: "file:///test/page.view.tree", Text: content}
+	
+	hover, err := provider.ProvideHover(document, Position{Line: 1, Character: 2})
+	if err != nil || hover == nil {
+		t.Fatalf("Expected hover for title, got %+v, %v", hover, err)
+	}
+	for _, want := range []string{
+		"**Component**: `$my_page`",
+		"title <= caption \\Hello",
+		"**Binding**: `<=` `caption`",
+		"**Default**: `\\Hello`",
+		"**Declared in**: `$my_base`",
+		"title(): string",
+		"Page heading",
+	} {
+		if !strings.Contains(hover.Contents.Value, want) {
+			t.Errorf("Expected title hover to contain %q, got:\n%s", want, hover.Contents.Value)
+		}
+	}
+	
+	hover, err = provider.ProvideHover(document, Position{Line: 1, Character: 12})
+	if err != nil || hover == nil {
+		t.Fatalf("Expected hover for caption, got %+v, %v", hover, err)
+	}
+	for _, want := range []string{"**Bound in this file**:", "- line 2: `title <= caption`", "- line 5: `item <= caption`", "- line 7: `$mol_button.title <= caption`", "- line 9: `hint <= caption` in `$my_page_sub`"} {
+		if !strings.Contains(hover.Contents.Value, want) {
+			t.Errorf("Expected caption hover to contain %q, got:\n%s", want, hover.Contents.Value)
+		}
+	}
+	if strings.Contains(hover.Contents.Value, "label <= caption") {
+		t.Errorf("Expected bindings of unrelated components to be left out, got:\n%s", hover.Contents.Value)
+	}
+	
+	hover, err = provider.ProvideHover(document, Position{Line: 6, Character: 3})
+	if err != nil || hover == nil || !strings.Contains(hover.Contents.Value, "**Overrides**: property of `$mol_button`") {
+		t.Errorf("Expected override hover to name the child class, got %+v", hover)
+	}
+	
+	// Long bodies are cut after a few nested lines
+	long := "$my_list $mol_view\n\tsub /\n\t\t<= A\n\t\t<= B\n\t\t<= C\n\t\t<= D\n\t\t<= E"
+	sub := BuildComponentDecls(ParseTree(long), "")[0].Property("sub")
+	if source := nodeSource(long, sub.Node); source != "sub /\n\t<= A\n\t<= B\n\t<= C\n\t…" {
+		t.Errorf("Expected a capped declaration, got %q", source)
+	}
+}
+
+func TestTypeDiagnostics(t *testing.T) {
+	scanner := NewProjectScanner("/test")
+	scanner.parseViewTreeFile("$my_toggle $my_root\n\tenabled true\n\tchecked? false\n\tlabel \\", "/test/toggle.view.tree")
+	scanner.parseViewTreeFile("$my_root\n\ttitle \\", "/test/root.view.tree")
+	provider := NewDiagnosticProvider(scanner)
+	
+	content := `$my_app $my_toggle
+	title @ \Hello
+	enabled <= title
+	numbers /number
+		1
+		\text
+		<= count 2
+	Toggle $my_toggle
+		checked? <=> done? false
+		label <= caption \Done
+		missing => exported
+		label => toggle_label`
+	diagnostics, err := provider.ProvideDiagnostics(&TextDocument{URI: "file:///test/app.view.tree", Text: content})
+	if err != nil {
+		t.Fatalf("ProvideDiagnostics failed: %v", err)
+	}
+	
+	var codes []string
+	for _, diagnostic := range diagnostics {
+		code := diagnostic.Code.(string)
+		if code == RuleTypeMismatch || code == RuleListItemType || code == RuleUnknownMember {
+			codes = append(codes, fmt.Sprintf("%s:%d", code, diagnostic.Range.Start.Line))
+		}
+	}
+	
+	expected := []string{RuleTypeMismatch + ":2", RuleListItemType + ":5", RuleUnknownMember + ":10"}
+	if strings.Join(codes, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, codes)
+	}
+}
+
+func TestUnknownMemberDiagnostics(t *testing.T) {
+	scanner := NewProjectScanner("/test")
+	scanner.parseViewTreeFile("$my_button $my_view\n\tcaption \\\n\tclick? null", "/test/button.view.tree")
+	scanner.parseViewTreeFile("$my_view\n\tsub /\n\ttitle \\", "/test/view.view.tree")
+	provider := NewDiagnosticProvider(scanner)
+	
+	content := `$my_app $my_view
+	sub /
+		^
+		<= Button $my_button
+			captoin \Go
+			clik? => button_click
+			title \Ok
+		<= Other $mol_unknown
+			anything \x
+	hint ^`
+	diagnostics, err := provider.ProvideDiagnostics(&TextDocument{URI: "file:///test/app.view.tree", Text: content})
+	if err != nil {
+		t.Fatalf("ProvideDiagnostics failed: %v", err)
+	}
+	
+	var messages []string
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Code == RuleUnknownMember {
+			messages = append(messages, fmt.Sprintf("%d: %s", diagnostic.Range.Start.Line, diagnostic.Message))
+		}
+	}
+	
+	expected := []string{
+		"4: '$my_button' has no property 'captoin'. Did you mean 'caption'?",
+		"5: '$my_button' has no property 'clik' to export with =>. Did you mean 'click'?",
+		"9: '$my_view' has no property 'hint' to override with ^",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected:\n%s\nGot:\n%s", strings.Join(expected, "\n"), strings.Join(messages, "\n"))
+	}
+}
+
+func TestUsageAnalysis(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"app/index.html":       "<div mol_view_root=\"$my_app\"></div>",
+		"app/app.view.tree":    "$my_app $my_view\n\tsub /\n\t\t<= Row $my_row\n\t\t\tlabel <= row_label \\",
+		"app/app.view.ts":      "namespace $.$$ {\n\texport class $my_app extends $.$my_app {\n\t\ttitle() {\n\t\t\treturn this.helper()\n\t\t}\n\t}\n}",
+		"my row/row.view.tree": "$my_row $my_view\n\tlabel \\\n\tstale \\\n\thelper \\",
+		"view/view.view.tree":  "$my_view\n\tsub /",
+		"dead/dead.view.tree":  "$my_dead $my_view",
+		"mention/mention.ts":   "const used = $my_used",
+		"used/used.view.tree":  "$my_used $my_view",
+		"base/base.view.tree":  "$my_base $my_view\n\textra \\hi",
+		"sub/index.html":       "<div mol_view_root=\"$my_sub\"></div>",
+		"sub/sub.view.tree":    "$my_sub $my_base\n\tsub /\n\t\t<= Label $my_view\n\t\t\tsub /\n\t\t\t\t<= extra",
+	}
+	writeTestFiles(t, root, files)
+	
+	scanner := NewProjectScanner(root)
+	if err := scanner.ScanProject(); err != nil {
+		t.Fatalf("ScanProject failed: %v", err)
+	}
+	
+	provider := NewDiagnosticProvider(scanner)
+	// The directory name is percent-encoded in the document URI
+	rowFile := filepath.Join(root, "my row", "row.view.tree")
+	diagnostics, err := provider.ProvideDiagnostics(&TextDocument{URI: pathToURI(rowFile), Text: files["my row/row.view.tree"]})
+	if err != nil {
+		t.Fatalf("ProvideDiagnostics failed: %v", err)
+	}
+	
+	var unused []string
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Code == RuleUnusedProperty || diagnostic.Code == RuleDeadComponent {
+			if len(diagnostic.Tags) != 1 || diagnostic.Tags[0] != DiagnosticTagUnnecessary {
+				t.Errorf("Expected the unnecessary tag on %s", diagnostic.Message)
+			}
+			unused = append(unused, diagnostic.Message)
+		}
+	}
+	if len(unused) != 1 || unused[0] != "Property 'stale' is never bound, overridden or used from TypeScript" {
+		t.Errorf("Expected only 'stale' to be unused, got %v", unused)
+	}
+	
+	unusedIn := func(file, content string) []string {
+		diagnostics, err := provider.ProvideDiagnostics(&TextDocument{URI: pathToURI(file), Text: content})
+		if err != nil {
+			t.Fatalf("ProvideDiagnostics failed: %v", err)
+		}
+		var result []string
+		for _, diagnostic := range diagnostics {
+			if diagnostic.Code == RuleUnusedProperty || diagnostic.Code == RuleDeadComponent {
+				result = append(result, diagnostic.Message)
+			}
+		}
+		return result
+	}
+	if result := unusedIn(filepath.Join(root, "base", "base.view.tree"), files["base/base.view.tree"]); len(result) != 0 {
+		t.Errorf("Expected properties bound by subclasses to be used, got %v", result)
+	}
+	
+	// Edits of the document reuse the index, edits elsewhere rebuild it
+	edited := files["my row/row.view.tree"] + "\n\tfresh \\"
+	scanner.UpdateSingleFile(rowFile, edited)
+	if result := unusedIn(rowFile, edited); len(result) != 2 {
+		t.Errorf("Expected 'stale' and 'fresh' to be unused, got %v", result)
+	}
+	appFile := filepath.Join(root, "app", "app.view.tree")
+	scanner.UpdateSingleFile(appFile, files["app/app.view.tree"]+"\n\t\t\tstale <= row_stale \\")
+	if result := unusedIn(rowFile, edited); len(result) != 1 || !strings.Contains(result[0], "'fresh'") {
+		t.Errorf("Expected the override in app to be seen, got %v", result)
+	}
+	scanner.UpdateSingleFile(appFile, files["app/app.view.tree"])
+	
+	// The document replaces its indexed version, so overrides it dropped no longer count
+	scanner.UpdateSingleFile(rowFile, files["my row/row.view.tree"]+"\n$my_row_sub $my_row\n\tstale \\sub")
+	if result := unusedIn(rowFile, files["my row/row.view.tree"]); len(result) != 1 || !strings.Contains(result[0], "'stale'") {
+		t.Errorf("Expected the indexed version of the document to be replaced, got %v", result)
+	}
+	scanner.UpdateSingleFile(rowFile, files["my row/row.view.tree"])
+	
+	var stdout, stderr bytes.Buffer
+	code := runLint([]string{"--unused", root}, &stdout, &stderr)
+	if code != 1 {
+		t.Errorf("Expected exit code 1, got %d (%s)", code, stderr.String())
+	}
+	expected := filepath.Join("dead", "dead.view.tree") + ":1:1: unused component $my_dead\n1 unused component(s)\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected lint output %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestCycleDiagnostics(t *testing.T) {
+	scanner := NewProjectScanner("/test")
+	scanner.parseViewTreeFile("$my_y $my_x\n\ttitle \\", "/test/y.view.tree")
+	provider := NewDiagnosticProvider(scanner)
+	
+	content := "$my_x $my_y\n\ta <= b\n\tb <= c \\\n\tc <= a\n\td <= e \\"
+	diagnostics, err := provider.ProvideDiagnostics(&TextDocument{URI: "file:///test/x.view.tree", Text: content})
+	if err != nil {
+		t.Fatalf("ProvideDiagnostics failed: %v", err)
+	}
+	
+	var binding, inheritance *Diagnostic
+	for i := range diagnostics {
+		switch diagnostics[i].Code {
+		case RuleBindingCycle:
+			if binding != nil {
+				t.Errorf("Expected the binding cycle to be reported once, got another: %s", diagnostics[i].Message)
+			}
+			binding = &diagnostics[i]
+		case RuleInheritanceCycle:
+			inheritance = &diagnostics[i]
+		}
+	}
+	
+	if binding == nil || binding.Message != "Binding cycle: a <= b <= c <= a" || binding.Severity != DiagnosticSeverityError {
+		t.Fatalf("Expected binding cycle error, got %+v", binding)
+	}
+	if len(binding.RelatedInformation) != 3 || binding.RelatedInformation[2].Message != "'c' <= 'a'" || binding.RelatedInformation[2].Location.Range.Start.Line != 3 {
+		t.Errorf("Expected related locations of all three bindings, got %+v", binding.RelatedInformation)
+	}
+	
+	if inheritance == nil || inheritance.Message != "Inheritance cycle: $my_x -> $my_y -> $my_x" {
+		t.Fatalf("Expected inheritance cycle error, got %+v", inheritance)
+	}
+	if len(inheritance.RelatedInformation) != 2 || inheritance.RelatedInformation[1].Location.URI != "file:///test/y.view.tree" {
+		t.Errorf("Expected related location in y.view.tree, got %+v", inheritance.RelatedInformation)
+	}
+}
+
+func TestLocaleStrings(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"my app/app.locale=ru.json": "{\n\t\"$my_app_title\": \"Привет\",\n\t\"$my_app_Selector_empty\": \"Пусто\"\n}\n",
+		"my app/app.locale=de.json": "{\n\t\"$my_app_title\": \"Hallo\"\n}\n",
+	})
+	scanner := NewProjectScanner(root)
+	
+	content := "$my_app $mol_page\n\ttitle @ \\Hello\n\tbody /\n\t\t<= Selector $mol_select\n\t\t\tempty @ \\Nothing <here>"
+	// The directory name is percent-encoded in the document URI
+	document := &TextDocument{URI: pathToURI(filepath.Join(root, "my app", "app.view.tree")), Text: content}
+	
+	decls := BuildComponentDecls(ParseTree(content), "")
+	var keys []string
+	for _, localized := range LocaleStrings(decls) {
+		keys = append(keys, localized.Key)
+	}
+	if strings.Join(keys, " ") != "$my_app_title $my_app_Selector_empty" {
+		t.Errorf("Unexpected locale keys %v", keys)
+	}
+	
+	hover, err := NewHoverProvider(scanner).ProvideHover(document, Position{Line: 4, Character: 12})
+	if err != nil || hover == nil {
+		t.Fatalf("Expected locale hover, got %+v, %v", hover, err)
+	}
+	for _, want := range []string{"`$my_app_Selector_empty`", "*source*: Nothing <here>", "`de`: *missing*", "`ru`: Пусто"} {
+		if !strings.Contains(hover.Contents.Value, want) {
+			t.Errorf("Expected locale hover to contain %q, got:\n%s", want, hover.Contents.Value)
+		}
+	}
+	
+	locations, err := NewDefinitionProvider(scanner).ProvideDefinition(document, Position{Line: 1, Character: 8})
+	if err != nil || len(locations) != 2 || !strings.HasSuffix(locations[0].TargetURI, "app.locale=de.json") || locations[1].TargetSelectionRange.Start.Line != 1 {
+		t.Errorf("Expected definitions in both locale files, got %+v", locations)
+	}
+	
+	diagnostics, err := NewDiagnosticProvider(scanner).ProvideDiagnostics(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var missing []Diagnostic
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Code == RuleMissingTranslation {
+			missing = append(missing, diagnostic)
+		}
+	}
+	if len(missing) != 1 || missing[0].Message != "Localized string '$my_app_Selector_empty' has no translation in: de" {
+		t.Fatalf("Expected one missing translation, got %+v", missing)
+	}
+	
+	actions := NewCodeActionProvider(scanner).ProvideCodeActions(document, CodeActionParams{
+		Range:   missing[0].Range,
+		Context: CodeActionContext{Diagnostics: missing},
+	})
+	if len(actions) != 1 || len(actions[0].Diagnostics) != 1 || actions[0].Kind != CodeActionKindQuickFix {
+		t.Fatalf("Expected one quick fix, got %+v", actions)
+	}
+	edits := actions[0].Edit.Changes[pathToURI(filepath.Join(root, "my app", "app.locale=de.json"))]
+	if len(edits) != 1 || edits[0].NewText != ",\n\t\"$my_app_Selector_empty\": \"Nothing <here>\"" || edits[0].Range.Start != (Position{Line: 1, Character: 25}) {
+		t.Errorf("Unexpected stub edit %+v", edits)
+	}
+	
+	empty := &LocaleFile{Content: "{}"}
+	if edit, ok := empty.StubEdit("$k", "v"); !ok || edit.NewText != "\n\t\"$k\": \"v\"\n" || edit.Range.End.Character != 1 {
+		t.Errorf("Unexpected stub edit for an empty file %+v", edit)
+	}
+	
+	viewTreeFile := filepath.Join(root, "my app", "app.view.tree")
+	first, second := LocaleFilesFor(viewTreeFile), LocaleFilesFor(viewTreeFile)
+	if len(first) != 2 || first[0] != second[0] {
+		t.Errorf("Expected unchanged locale files to be reused, got %v and %v", first, second)
+	}
+	if err := os.WriteFile(filepath.Join(root, "my app", "app.locale=de.json"), []byte("{\n\t\"$my_app_title\": \"Guten Tag\"\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if files := LocaleFilesFor(viewTreeFile); files[0].Entries["$my_app_title"] != "Guten Tag" {
+		t.Errorf("Expected changed locale files to be read again, got %v", files[0].Entries)
+	}
+}
+
+func TestDefinitionLinks(t *testing.T) {
+	root := t.TempDir()
+	app := "$my_app $my_base\n\ttitle \\App\n\tHead $mol_view\n\tButton $my_button\n\t\tcaption <= label \\Go"
+	writeTestFiles(t, root, map[string]string{
+		"my/app/app.view.tree":       app,
+		"my/app/app.view.ts":         "namespace $.$$ {\n\texport class $my_app extends $.$my_app {\n\t\ttitle() {\n\t\t\treturn ''\n\t\t}\n\t}\n}",
+		"my/app/app.view.css.ts":     "namespace $.$$ {\n\t$mol_style_define( $my_app, {\n\t\tHead: {\n\t\t\tpadding: 0,\n\t\t},\n\t})\n}",
+		"my/base/base.view.tree":     "$my_base $mol_view\n\ttitle \\",
+		"my/button/button.view.tree": "$my_button $mol_view\n\tcaption \\",
+	})
+	scanner := NewProjectScanner(root)
+	if err := scanner.ScanProject(); err != nil {
+		t.Fatal(err)
+	}
+	provider := NewDefinitionProvider(scanner)
+	document := &TextDocument{URI: "file://" + filepath.Join(root, "my", "app", "app.view.tree"), Text: app}
+	
+	target := func(links []LocationLink, index int) string {
+		if index >= len(links) {
+			return ""
+		}
+		link := links[index]
+		file := strings.TrimPrefix(link.TargetURI, "file://"+root+"/")
+		return fmt.Sprintf("%s:%d:%d", file, link.TargetSelectionRange.Start.Line, link.TargetSelectionRange.Start.Character)
+	}
+	
+	testCases := []struct {
+		name     string
+		document *TextDocument
+		position Position
+		want     []string
+	}{
+		{"root class", document, Position{Line: 0, Character: 1}, []string{"my/app/app.view.ts:1:14"}},
+		{"component", document, Position{Line: 3, Character: 10}, []string{"my/button/button.view.tree:0:0"}},
+		{"typescript override", document, Position{Line: 1, Character: 2}, []string{"my/app/app.view.ts:2:2"}},
+		{"css rule", document, Position{Line: 2, Character: 2}, []string{"my/app/app.view.css.ts:2:2"}},
+		{"child property", document, Position{Line: 4, Character: 3}, []string{"my/button/button.view.tree:1:1"}},
+		{"unknown component", document, Position{Line: 2, Character: 8}, nil},
+		{"view.tree and typescript", &TextDocument{URI: "file://" + filepath.Join(root, "my", "page", "page.view.tree"), Text: "$my_page $my_app"}, Position{Line: 0, Character: 11},
+			[]string{"my/app/app.view.tree:0:0", "my/app/app.view.ts:1:14"}},
+	}
+	
+	// With one TypeScript file indexed, app.view.ts is read from disk
+	for _, maxTsFiles := range []int{0, 1} {
+		settings := DefaultSettings()
+		settings.MaxTsFiles = maxTsFiles
+		scanner.SetSettings(settings)
+		if err := scanner.ScanProject(); err != nil {
+			t.Fatal(err)
+		}
+		if indexed := scanner.GetTsClass("$my_app") != nil; indexed != (maxTsFiles == 0) {
+			t.Fatalf("Expected $my_app to be indexed: %v, got %v", maxTsFiles == 0, indexed)
+		}
+		
+		for _, tc := range testCases {
+			links, err := provider.ProvideDefinition(tc.document, tc.position)
+			if err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			if len(links) != len(tc.want) {
+				t.Errorf("%s (maxTsFiles %d): expected %d links, got %+v", tc.name, maxTsFiles, len(tc.want), links)
+				continue
+			}
+			for i, want := range tc.want {
+				if got := target(links, i); got != want {
+					t.Errorf("%s (maxTsFiles %d): expected link %d to %s, got %s", tc.name, maxTsFiles, i, want, got)
+				}
+				if links[i].OriginSelectionRange == nil || !rangeContains(*links[i].OriginSelectionRange, tc.position) {
+					t.Errorf("%s: expected origin range around the cursor, got %+v", tc.name, links[i].OriginSelectionRange)
+				}
+			}
+		}
+	}
+	
+	if locations := locationsFromLinks(nil); locations == nil || len(locations) != 0 {
+		t.Errorf("Expected an empty location list, got %+v", locations)
+	}
+}
+
+func TestNavigationRequests(t *testing.T) {
+	root := t.TempDir()
+	app := "$my_app $my_base\n\ttitle \\App\n\tsub /\n\t\t<= Selector $my_select\n\t\t\tvalue <= title"
+	writeTestFiles(t, root, map[string]string{
+		"my/app/app.view.tree":       app,
+		"my/base/base.view.tree":     "$my_base $mol_view\n\ttitle \\",
+		"my/select/select.view.tree": "$my_select $mol_view\n\tvalue \\",
+		"my/select/select.view.ts":   "namespace $.$$ {\n\texport class $my_select extends $.$my_select {\n\t\tvalue() {\n\t\t\treturn ''\n\t\t}\n\t}\n}",
+		"my/fancy/fancy.view.tree":   "$my_fancy $my_select",
+		"my/fancy/fancy.view.ts":     "namespace $.$$ {\n\texport class $my_fancy extends $.$my_fancy {\n\t\tvalue() {\n\t\t\treturn 'fancy'\n\t\t}\n\t}\n}",
+	})
+	scanner := NewProjectScanner(root)
+	if err := scanner.ScanProject(); err != nil {
+		t.Fatal(err)
+	}
+	provider := NewDefinitionProvider(scanner)
+	document := &TextDocument{URI: "file://" + filepath.Join(root, "my", "app", "app.view.tree"), Text: app}
+	
+	targets := func(links []LocationLink) string {
+		var result []string
+		for _, link := range links {
+			file := strings.TrimPrefix(link.TargetURI, "file://"+root+"/")
+			result = append(result, fmt.Sprintf("%s:%d:%d", file, link.TargetSelectionRange.Start.Line, link.TargetSelectionRange.Start.Character))
+		}
+		return strings.Join(result, " ")
+	}
+	
+	testCases := []struct {
+		name     string
+		provide  func(*TextDocument, Position) []LocationLink
+		position Position
+		want     string
+	}{
+		{"declaration of an inherited property", provider.ProvideDeclaration, Position{Line: 1, Character: 2}, "my/base/base.view.tree:1:1"},
+		{"declaration of a bound property", provider.ProvideDeclaration, Position{Line: 4, Character: 13}, "my/app/app.view.tree:1:1"},
+		{"declaration of a component", provider.ProvideDeclaration, Position{Line: 0, Character: 10}, "my/base/base.view.tree:0:0"},
+		{"type definition of an instance", provider.ProvideTypeDefinition, Position{Line: 3, Character: 6}, "my/select/select.view.tree:0:0 my/select/select.view.ts:1:14"},
+		{"type definition of a string", provider.ProvideTypeDefinition, Position{Line: 1, Character: 2}, ""},
+		{"implementation of an override", provider.ProvideImplementation, Position{Line: 4, Character: 4}, "my/select/select.view.ts:2:2 my/fancy/fancy.view.ts:2:2"},
+		{"implementation of a component", provider.ProvideImplementation, Position{Line: 3, Character: 16}, "my/select/select.view.ts:1:14 my/fancy/fancy.view.ts:1:14"},
+		{"operator", provider.ProvideDeclaration, Position{Line: 3, Character: 2}, ""},
+	}
+	
+	for _, tc := range testCases {
+		if got := targets(tc.provide(document, tc.position)); got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestTypeHierarchy(t *testing.T) {
+	root := t.TempDir()
+	app := "$my_app $my_base\n\ttitle \\App"
+	writeTestFiles(t, root, map[string]string{
+		"my/base/base.view.tree": "$my_base $mol_view",
+		"my/base/base.view.ts":   "namespace $.$$ {\n\texport class $my_base extends $.$my_base {\n\t}\n}",
+		"my/app/app.view.tree":   app,
+		"my/app/app.view.ts":     "namespace $.$$ {\n\texport class $my_app extends $.$my_app {\n\t}\n}",
+		"my/tool/tool.ts":        "namespace $ {\n\texport class $my_tool extends $my_app {\n\t}\n}",
+	})
+	scanner := NewProjectScanner(root)
+	if err := scanner.ScanProject(); err != nil {
+		t.Fatal(err)
+	}
+	provider := NewTypeHierarchyProvider(scanner)
+	
+	describe := func(items []TypeHierarchyItem) string {
+		var result []string
+		for _, item := range items {
+			// Items travel through the client as JSON
+			data, _ := json.Marshal(item)
+			var decoded TypeHierarchyItem
+			if err := json.Unmarshal(data, &decoded); err != nil || decoded.Data == nil {
+				t.Fatalf("Item did not survive a round trip: %s", data)
+			}
+			result = append(result, decoded.Data.Source+":"+decoded.Name)
+		}
+		return strings.Join(result, " ")
+	}
+	
+	items := provider.PrepareTypeHierarchy(&TextDocument{URI: "file://" + filepath.Join(root, "my", "app", "app.view.tree"), Text: app}, Position{Line: 0, Character: 2})
+	if describe(items) != "view.tree:$my_app" || items[0].Detail != "view.tree extends $my_base" {
+		t.Fatalf("Unexpected prepared items %+v", items)
+	}
+	appTree := items[0]
+	
+	// Items of a document under an escaped path point back at the document
+	spacedURI := pathToURI(filepath.Join(root, "my app", "app.view.tree"))
+	if spaced := provider.PrepareTypeHierarchy(&TextDocument{URI: spacedURI, Text: app}, Position{Line: 0, Character: 2}); len(spaced) != 1 || spaced[0].URI != spacedURI {
+		t.Errorf("Expected the item to point at %s, got %+v", spacedURI, spaced)
+	}
+	
+	baseTs := provider.Supertypes(appTree)
+	if describe(baseTs) != "ts:$my_base" || baseTs[0].Detail != "$.$$ override" {
+		t.Fatalf("Expected the $.$$ class of $my_base as supertype, got %+v", baseTs)
+	}
+	baseTree := provider.Supertypes(baseTs[0])
+	if describe(baseTree) != "view.tree:$my_base" {
+		t.Fatalf("Expected the view.tree class of $my_base, got %+v", baseTree)
+	}
+	if got := describe(provider.Supertypes(baseTree[0])); got != "" {
+		t.Errorf("Expected no indexed supertype of $my_base, got %s", got)
+	}
+	
+	if got := describe(provider.Subtypes(baseTree[0])); got != "ts:$my_base" {
+		t.Errorf("Expected the $.$$ class as subtype, got %s", got)
+	}
+	if got := describe(provider.Subtypes(baseTs[0])); got != "view.tree:$my_app" {
+		t.Errorf("Expected $my_app as subtype of $my_base, got %s", got)
+	}
+	appTs := provider.Subtypes(appTree)
+	if describe(appTs) != "ts:$my_app" {
+		t.Fatalf("Expected the $.$$ class of $my_app, got %+v", appTs)
+	}
+	if got := describe(provider.Subtypes(appTs[0])); got != "ts:$my_tool" {
+		t.Errorf("Expected TypeScript subclass $my_tool, got %s", got)
+	}
+}
+
+func TestCallHierarchy(t *testing.T) {
+	root := t.TempDir()
+	app := `$my_app $mol_page
+	selector_value? \
+	body /
+		<= Selector $my_select
+			value? <=> selector_value?
+			focused => selector_focused
+		<= Status $mol_view
+			title <= status
+	status \`
+	writeTestFiles(t, root, map[string]string{
+		"my/app/app.view.tree": app,
+		"my/app/app.view.ts":   "namespace $.$$ {\n\texport class $my_app extends $.$my_app {\n\t\tstatus() {\n\t\t\treturn this.selector_value() + String( this.selector_focused() )\n\t\t}\n\t}\n}",
+	})
+	scanner := NewProjectScanner(root)
+	if err := scanner.ScanProject(); err != nil {
+		t.Fatal(err)
+	}
+	provider := NewCallHierarchyProvider(scanner)
+	
+	items := provider.PrepareCallHierarchy(&TextDocument{URI: "file://" + filepath.Join(root, "my", "app", "app.view.tree"), Text: app}, Position{Line: 1, Character: 3})
+	if len(items) != 1 || items[0].Name != "selector_value" || items[0].Detail != "$my_app" {
+		t.Fatalf("Unexpected prepared items %+v", items)
+	}
+	spacedURI := pathToURI(filepath.Join(root, "my app", "app.view.tree"))
+	if spaced := provider.PrepareCallHierarchy(&TextDocument{URI: spacedURI, Text: app}, Position{Line: 1, Character: 3}); len(spaced) != 1 || spaced[0].URI != spacedURI {
+		t.Errorf("Expected the item to point at %s, got %+v", spacedURI, spaced)
+	}
+	
+	item := func(property, source string) CallHierarchyItem {
+		found := provider.item(provider.typeInferrer, "$my_app", property, source)
+		if found == nil {
+			t.Fatalf("No item for %s", property)
+		}
+		return *found
+	}
+	describeIncoming := func(calls []CallHierarchyIncomingCall) string {
+		var result []string
+		for _, call := range calls {
+			result = append(result, fmt.Sprintf("%s:%s@%d:%d", call.From.Data.Source, call.From.Name, call.FromRanges[0].Start.Line, call.FromRanges[0].Start.Character))
+		}
+		return strings.Join(result, " ")
+	}
+	describeOutgoing := func(calls []CallHierarchyOutgoingCall) string {
+		var result []string
+		for _, call := range calls {
+			result = append(result, fmt.Sprintf("%s@%d:%d", call.To.Name, call.FromRanges[0].Start.Line, call.FromRanges[0].Start.Character))
+		}
+		return strings.Join(result, " ")
+	}
+	
+	if got := describeIncoming(provider.IncomingCalls(items[0])); got != "view.tree:Selector.value@4:14 ts:status@3:15" {
+		t.Errorf("Unexpected callers of selector_value: %s", got)
+	}
+	if got := describeIncoming(provider.IncomingCalls(item("status", HierarchySourceTree))); got != "view.tree:Status.title@7:12" {
+		t.Errorf("Unexpected callers of status: %s", got)
+	}
+	
+	outgoing := map[string]string{
+		"Selector.value":   "selector_value@4:14",
+		"Selector":         "Selector.value@4:3 Selector.focused@5:3",
+		"selector_focused": "Selector.focused@5:3",
+		"body":             "Selector@3:5 Status@6:5",
+	}
+	for property, want := range outgoing {
+		if got := describeOutgoing(provider.OutgoingCalls(item(property, HierarchySourceTree))); got != want {
+			t.Errorf("Unexpected calls of %s: expected %s, got %s", property, want, got)
+		}
+	}
+	if got := describeOutgoing(provider.OutgoingCalls(item("status", HierarchySourceTs))); got != "selector_value@3:15 selector_focused@3:47" {
+		t.Errorf("Unexpected calls of the status method: %s", got)
+	}
+}
+
+func TestCompletionContext(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"mol/view/view.view.tree": "$mol_view $mol_object\n\tsub /\n\tdom_name \\\n",
+		"my/item/item.view.tree":  "$my_item $mol_view\n\tcaption \\\n",
+	})
+	scanner := NewProjectScanner(root)
+	if err := scanner.ScanProject(); err != nil {
+		t.Fatal(err)
+	}
+	provider := NewCompletionProvider(scanner)
+	
+	app := `$my_app $mol_view
+	title \Hello
+	flag false
+	flags /boolean
+		
+	sub /
+		<= Item $my_item
+			
+		<= 
+	`
+	complete := func(line, character int) (string, map[string]CompletionItem) {
+		completionContext := provider.getCompletionContext(app, Position{Line: line, Character: character}, "")
+		items, _ := provider.ProvideCompletionItems(&TextDocument{URI: "file://" + filepath.Join(root, "my", "app", "app.view.tree"), Text: app}, Position{Line: line, Character: character})
+		labels := make(map[string]CompletionItem)
+		for _, item := range items {
+			labels[item.Label] = item
+		}
+		return completionContext.Type, labels
+	}
+	
+	if context, _ := complete(0, 8); context != "component_extends" {
+		t.Errorf("Expected base class completion after the root name, got %s", context)
+	}
+	if context, items := complete(9, 1); context != "property_name" || items["title"].SortText != "1title" || items["dom_name"].SortText != "2dom_name" || items["/"].Label == "" {
+		t.Errorf("Expected own and inherited properties, got %s %+v", context, items)
+	}
+	if context, items := complete(7, 3); context != "property_name" || items["caption"].Label == "" || items["title"].Label != "" {
+		t.Errorf("Expected properties of the child component, got %s %+v", context, items)
+	}
+	if context, items := complete(8, 5); context != "bindable_property" || items["flag"].Label == "" || items["<=>"].Label != "" || items["/"].Label != "" {
+		t.Errorf("Expected bindable properties after <=, got %s %+v", context, items)
+	}
+	if context, items := complete(4, 2); context != "list_item" || items["true"].Label == "" || items["\\"].Label != "" || items["<="].Label == "" {
+		t.Errorf("Expected boolean list items, got %s %+v", context, items)
+	}
+	if context, items := complete(2, 6); context != "property_binding" || items["<=>"].Label == "" || items["null"].Label == "" {
+		t.Errorf("Expected operators and values after a property, got %s %+v", context, items)
+	}
+	if context, items := complete(1, 10); context != "none" || len(items) != 0 {
+		t.Errorf("Expected no completion inside a string, got %s %+v", context, items)
+	}
+}
+
+func TestCompletionEdits(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"mol/view/view.view.tree":     "$mol_view $mol_object\n\tsub /\n",
+		"mol/button/button.view.tree": "$mol_button $mol_view\n",
+		"my/item/item.view.tree":      "$my_item $mol_view\n\tvalue? \\\n",
+	})
+	scanner := NewProjectScanner(root)
+	if err := scanner.ScanProject(); err != nil {
+		t.Fatal(err)
+	}
+	provider := NewCompletionProvider(scanner)
+	provider.SetClient(CompletionClient{SnippetSupport: true, InsertReplaceSupport: true, CommitCharactersSupport: true, AdjustIndentationSupport: true})
+	
+	app := "$my_app $mol_view\n\tsub /\n\t\t<= Item $my_item\n\t\t\tval\n\ttitle $mol_bu\n\tsu\n\tflag <"
+	complete := func(line, character int) map[string]CompletionItem {
+		items, _ := provider.ProvideCompletionItems(&TextDocument{URI: "file://" + filepath.Join(root, "my", "app", "app.view.tree"), Text: app}, Position{Line: line, Character: character})
+		labels := make(map[string]CompletionItem)
+		for _, item := range items {
+			labels[item.Label] = item
+		}
+		return labels
+	}
+	
+	button := complete(4, 14)["$mol_button"]
+	edit, ok := button.TextEdit.(*InsertReplaceEdit)
+	if !ok || edit.NewText != "$mol_button" || edit.Insert.Start.Character != 7 || edit.Insert.End.Character != 14 || edit.Replace.End.Character != 14 {
+		t.Errorf("Expected the typed component name to be replaced, got %+v", button.TextEdit)
+	}
+	if len(button.CommitCharacters) != 1 || button.CommitCharacters[0] != " " {
+		t.Errorf("Expected space to commit a component, got %v", button.CommitCharacters)
+	}
+	
+	if edit, ok := complete(6, 7)["<="].TextEdit.(*InsertReplaceEdit); !ok || edit.Insert.Start.Character != 6 {
+		t.Errorf("Expected the typed operator to be replaced, got %+v", edit)
+	}
+	
+	value := complete(3, 6)["value"]
+	if value.InsertTextFormat != InsertTextFormatSnippet || value.TextEdit.(*InsertReplaceEdit).NewText != "value? <=> ${1:value}? ${2:null}" {
+		t.Errorf("Expected a two-way binding scaffold for a mutable override, got %+v", value)
+	}
+	sub := complete(5, 3)["sub"]
+	if sub.TextEdit.(*InsertReplaceEdit).NewText != "sub /\n\t$0" || sub.InsertTextMode != InsertTextModeAdjustIndentation || sub.CommitCharacters != nil {
+		t.Errorf("Expected a nested list item placeholder, got %+v", sub)
+	}
+	if child := complete(4, 7)["<= Name $component"]; child.InsertText != "<= ${1:Name} \\$${2:component}" {
+		t.Errorf("Expected a child component snippet, got %+v", child)
+	}
+	
+	// Clients without snippets get plain text edits
+	provider.SetClient(CompletionClient{})
+	if edit, ok := complete(3, 6)["value"].TextEdit.(*TextEdit); !ok || edit.NewText != "value?" || edit.Range.Start.Character != 3 {
+		t.Errorf("Expected a plain override, got %+v", edit)
+	}
+	items := complete(4, 7)
+	if _, ok := items["<= Name $component"]; ok || items["\\"].InsertText != "\\" || items["$mol_button"].CommitCharacters != nil {
+		t.Errorf("Expected no snippets or commit characters, got %+v", items)
+	}
+}
+
+func TestCompletionResolve(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"mol/view/view.view.tree": "$mol_view $mol_object\n\tsub /\n\ttitle \\\n",
+		"my/item/item.view.tree":  "$my_item $mol_view\n\tvalue? \\\n",
+		"my/item/item.view.ts":    "namespace $.$$ {\n\t/** Editable item */\n\texport class $my_item extends $.$my_item {\n\t\t/** Current value */\n\t\tvalue( next?: string ): string {\n\t\t\treturn next ?? ''\n\t\t}\n\t}\n}",
+	})
+	server := NewServer()
+	var output bytes.Buffer
+	server.writer = &output
+	workspace := NewWorkspace(WorkspaceFolder{Name: "test"}, root)
+	if err := workspace.projectScanner.ScanProject(); err != nil {
+		t.Fatal(err)
+	}
+	server.workspaces.Add(workspace)
+	
+	// Lists are lightweight, documentation comes from resolve
+	uri := "file://" + filepath.Join(root, "my", "app", "app.view.tree")
+	items, _ := workspace.completionProvider.ProvideCompletionItems(&TextDocument{URI: uri, Text: "$my_app $my_item\n\t"}, Position{Line: 1, Character: 1})
+	var value CompletionItem
+	for _, item := range items {
+		if item.Documentation != nil && item.Kind != CompletionItemKindOperator {
+			t.Errorf("Expected no documentation before resolve, got %+v", item)
+		}
+		if item.Label == "value" {
+			value = item
+		}
+	}
+	if data, ok := value.Data.(*CompletionItemData); !ok || data.URI != uri || data.Component != "$my_item" || data.Property != "value" {
+		t.Fatalf("Unexpected item data %+v", value.Data)
+	}
+	
+	request, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 7, "method": "completionItem/resolve", "params": value})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := server.handleMessage(request); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	for _, want := range []string{"Current value", "value(next?: string): string", "value? \\", `my/item/item.view.tree:2`} {
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("Expected resolved property documentation to contain %q, got: %s", want, output.String())
+		}
+	}
+	
+	component := workspace.completionProvider.ResolveCompletionItem(CompletionItem{Label: "$my_item"}, CompletionItemData{URI: uri, Component: "$my_item"})
+	documentation, _ := component.Documentation.(MarkupContent)
+	for _, want := range []string{"`$my_item` → `$mol_view` → `$mol_object`", "Editable item", "**Properties**: `value?`", "my/item/item.view.tree"} {
+		if !strings.Contains(documentation.Value, want) {
+			t.Errorf("Expected component documentation to contain %q, got: %s", want, documentation.Value)
+		}
+	}
+}
+
+func TestCompletionRanking(t *testing.T) {
+	root := t.TempDir()
+	var many strings.Builder
+	for i := 0; i < 150; i++ {
+		fmt.Fprintf(&many, "$lib_item%03d $mol_view\n", i)
+	}
+	writeTestFiles(t, root, map[string]string{
+		"lib/lib.view.tree":                many.String(),
+		"my/app/button/button.view.tree":   "$my_app_button $mol_view\n",
+		"my/other/button/button.view.tree": "$my_other_button $mol_view\n",
+		"my/other/toggle/toggle.view.tree": "$my_other_toggle $mol_view\n",
+		"my/other/form/form.view.tree":     "$my_other_form $mol_view\n\tsub /\n\t\t<= A $my_other_toggle\n\t\t<= B $my_other_toggle\n",
+	})
+	server := NewServer()
+	var output bytes.Buffer
+	server.writer = &output
+	workspace := NewWorkspace(WorkspaceFolder{Name: "test"}, root)
+	if err := workspace.projectScanner.ScanProject(); err != nil {
+		t.Fatal(err)
+	}
+	server.workspaces.Add(workspace)
+	provider := workspace.completionProvider
+	
+	complete := func(text string, character int) *CompletionList {
+		document := &TextDocument{URI: "file://" + filepath.Join(root, "my", "app", "page", "page.view.tree"), Text: text}
+		list, err := provider.ProvideCompletionList(document, Position{Line: 1, Character: character})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return list
+	}
+	labels := func(list *CompletionList) []string {
+		var result []string
+		for _, item := range list.Items {
+			result = append(result, item.Label)
+		}
+		return result
+	}
+	
+	// Everything matches an empty word: the list is capped and incomplete
+	if list := complete("$my_app_page $mol_view\n\tbody <= Body ", 21); !list.IsIncomplete || len(list.Items) != completionListLimit {
+		t.Errorf("Expected a capped incomplete list, got %d items, incomplete %v", len(list.Items), list.IsIncomplete)
+	}
+	
+	// Fuzzy matches rank by namespace proximity, then by usage
+	list := complete("$my_app_page $mol_view\n\tbody <= Body mybt", 25)
+	if list.IsIncomplete || strings.Join(labels(list), " ") != "$my_app_button $my_other_button" {
+		t.Errorf("Unexpected fuzzy matches %v", labels(list))
+	}
+	if list.Items[0].SortText != "0000" || list.Items[0].Command == nil || list.Items[0].Command.Command != CompletionAcceptedCommand {
+		t.Errorf("Expected ranked items with the accept command, got %+v", list.Items[0])
+	}
+	if got := labels(complete("$my_app_page $mol_view\n\tbody <= Body $my_other_", 31)); strings.Join(got, " ") != "$my_other_toggle $my_other_button $my_other_form" {
+		t.Errorf("Expected used components first, got %v", got)
+	}
+	
+	// Accepted items rank first afterwards
+	request := `{"jsonrpc":"2.0","id":3,"method":"workspace/executeCommand","params":{"command":"viewTree.completionAccepted","arguments":["$my_other_form"]}}`
+	if err := server.handleMessage([]byte(request)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if got := labels(complete("$my_app_page $mol_view\n\tbody <= Body $my_other_", 31)); got[0] != "$my_other_form" {
+		t.Errorf("Expected the recently used component first, got %v", got)
+	}
+	
+	// Edits recount the edited file only, counts handed out stay unchanged
+	usages := provider.componentUsages()
+	if usages["$my_other_toggle"] != 2 || usages["$my_other_button"] != 0 {
+		t.Errorf("Unexpected usages %v", usages)
+	}
+	menuFile := filepath.Join(root, "my", "other", "menu", "menu.view.tree")
+	usingButtons := "$my_other_menu $mol_view\n\tsub /\n\t\t<= A $my_other_button\n\t\t<= B $my_other_button\n\t\t<= C $my_other_button\n"
+	workspace.projectScanner.UpdateSingleFile(menuFile, usingButtons)
+	if edited := provider.componentUsages(); edited["$my_other_button"] != 3 || edited["$mol_view"] != usages["$mol_view"]+1 || usages["$my_other_button"] != 0 {
+		t.Errorf("Expected the edited file to be counted, got %v", edited)
+	}
+	workspace.projectScanner.UpdateSingleFile(menuFile, "$my_other_menu $mol_view\n\tsub /\n\t\t<= A $my_other_button\n")
+	if edited := provider.componentUsages(); edited["$my_other_button"] != 1 {
+		t.Errorf("Expected the edited file to be counted again, got %v", edited)
+	}
+	
+	// Several updates at once and full scans count everything again
+	writeTestFiles(t, root, map[string]string{"my/other/menu/menu.view.tree": usingButtons})
+	workspace.projectScanner.UpdateSingleFile(menuFile, usingButtons)
+	workspace.projectScanner.UpdateSingleFile(filepath.Join(root, "my", "other", "form", "form.view.tree"), "$my_other_form $mol_view\n")
+	if edited := provider.componentUsages(); edited["$my_other_button"] != 3 || edited["$my_other_toggle"] != 0 {
+		t.Errorf("Expected all updates to be counted, got %v", edited)
+	}
+	if err := workspace.projectScanner.ScanProject(); err != nil {
+		t.Fatal(err)
+	}
+	if scanned := provider.componentUsages(); scanned["$my_other_button"] != 3 || scanned["$my_other_toggle"] != 2 {
+		t.Errorf("Expected usages to be counted again after a scan, got %v", scanned)
+	}
+	
+	// The MAM path of an empty document is read from its decoded URI
+	spacedRoot := filepath.Join(t.TempDir(), "my ws")
+	spaced := NewCompletionProvider(NewProjectScanner(spacedRoot))
+	if namespace := spaced.documentNamespace(&TextDocument{URI: pathToURI(filepath.Join(spacedRoot, "my", "app", "page.view.tree"))}); strings.Join(namespace, "_") != "my_app" {
+		t.Errorf("Expected the namespace of the document path, got %v", namespace)
+	}
+}
+
+func TestChildScaffolds(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"mol/view/view.view.tree":    "$mol_view $mol_object\n\tsub /\n",
+		"my/button/button.view.tree": "$my_button $mol_view\n\ttitle \\\n\tclick? null\n\tenabled true\n",
+		"my/form/form.view.tree":     "$my_form $mol_view\n\tsub /\n\t\t<= Save $my_button\n\t\t\tclick? <=> save? null\n\t\t\tenabled <= can_save true\n\t\t<= Cancel $my_button\n\t\t\tclick? <=> cancel? null\n",
+	})
+	scanner := NewProjectScanner(root)
+	if err := scanner.ScanProject(); err != nil {
+		t.Fatal(err)
+	}
+	provider := NewCompletionProvider(scanner)
+	provider.SetClient(CompletionClient{SnippetSupport: true, AdjustIndentationSupport: true})
+	
+	app := "$my_app $mol_view\n\tsub /\n\t\t<= Title $mol_view\n\t\t\n\tButton $mol_view\n\t"
+	scaffold := func(line, character int) CompletionItem {
+		items, _ := provider.ProvideCompletionItems(&TextDocument{URI: "file://" + filepath.Join(root, "my", "app", "app.view.tree"), Text: app}, Position{Line: line, Character: character})
+		for _, item := range items {
+			if item.Label == "$my_button" && item.Kind == CompletionItemKindSnippet {
+				return item
+			}
+		}
+		t.Fatalf("No scaffold for $my_button at %d:%d", line, character)
+		return CompletionItem{}
+	}
+	
+	// In a list the child is bound with its most overridden properties
+	if item := scaffold(3, 2); item.InsertText != "<= ${1:Button} \\$my_button\n\tclick? <=> ${2:button_click}? ${3:null}\n\tenabled <= ${4:button_enabled}" || len(item.AdditionalTextEdits) != 0 {
+		t.Errorf("Unexpected list scaffold %q %+v", item.InsertText, item.AdditionalTextEdits)
+	}
+	
+	// At the top level it becomes a property, added to the sub list
+	item := scaffold(5, 1)
+	if item.InsertText != "Button2 \\$my_button\n\tclick? <=> ${2:button2_click}? ${3:null}\n\tenabled <= ${4:button2_enabled}" {
+		t.Errorf("Unexpected property scaffold %q", item.InsertText)
+	}
+	if len(item.AdditionalTextEdits) != 1 || item.AdditionalTextEdits[0].NewText != "\n\t\t<= Button2" || item.AdditionalTextEdits[0].Range.Start != (Position{Line: 2, Character: 20}) {
+		t.Errorf("Expected the child to be added to the sub list, got %+v", item.AdditionalTextEdits)
+	}
+	
+	// Clients without indentation adjustment get the lines indented by the server
+	provider.SetClient(CompletionClient{})
+	if edit := scaffold(3, 2).TextEdit.(*TextEdit); edit.NewText != "<= Button $my_button\n\t\t\tclick? <=> button_click? null\n\t\t\tenabled <= button_enabled" {
+		t.Errorf("Unexpected plain scaffold %q", edit.NewText)
+	}
+	
+	// Only components matching the typed word get a scaffold
+	typedApp := "$my_app $mol_view\n\tsub /\n\t\tmybut"
+	items, _ := provider.ProvideCompletionItems(&TextDocument{URI: "file://" + filepath.Join(root, "my", "app", "app.view.tree"), Text: typedApp}, Position{Line: 2, Character: 7})
+	scaffolds := 0
+	for _, item := range items {
+		if item.Kind == CompletionItemKindSnippet {
+			scaffolds++
+			if item.Label != "$my_button" {
+				t.Errorf("Expected no scaffold for %s", item.Label)
+			}
+		}
+	}
+	if scaffolds != 1 {
+		t.Errorf("Expected the $my_button scaffold, got %d scaffolds", scaffolds)
+	}
+	
+	// Overrides of edited files count right away
+	scanner.UpdateSingleFile(filepath.Join(root, "my", "bar", "bar.view.tree"), "$my_bar $mol_view\n\tsub /\n\t\t<= A $my_button\n\t\t\ttitle \\A\n\t\t<= B $my_button\n\t\t\ttitle \\B\n\t\t<= C $my_button\n\t\t\ttitle \\C\n")
+	if edit := scaffold(3, 2).TextEdit.(*TextEdit); edit.NewText != "<= Button $my_button\n\t\t\ttitle <= button_title\n\t\t\tclick? <=> button_click? null" {
+		t.Errorf("Expected the overrides of the edited file to rank first, got %q", edit.NewText)
+	}
+	
+	if name := childName("$"); name != "" {
+		t.Errorf("Expected no child name for a bare $, got %q", name)
+	}
+}
+
+func TestSignatureHelp(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"mol/view/view.view.tree": "$mol_view $mol_object\n\tsub /\n",
+		"my/item/item.view.tree":  "$my_item $mol_view\n\tvalue? \\\n\tcount? 0\n",
+		"my/item/item.view.ts":    "namespace $.$$ {\n\texport class $my_item extends $.$my_item {\n\t\t/** Current value */\n\t\tvalue( next?: string ): string {\n\t\t\treturn next ?? ''\n\t\t}\n\t}\n}",
+	})
+	scanner := NewProjectScanner(root)
+	if err := scanner.ScanProject(); err != nil {
+		t.Fatal(err)
+	}
+	provider := NewSignatureHelpProvider(scanner)
+	
+	app := "$my_app $mol_view\n\tsub /\n\t\t<= Item $my_item\n\t\t\tvalue? <=> item_value? \n\t\t\tcount? <=> \n\t\t<= Row*0 $mol_view"
+	help := func(line, character int) *SignatureHelp {
+		return provider.ProvideSignatureHelp(&TextDocument{URI: "file://" + filepath.Join(root, "my", "app", "app.view.tree"), Text: app}, Position{Line: line, Character: character})
+	}
+	
+	value := help(3, 10)
+	if value == nil || value.Signatures[0].Label != "value(next?: string): string" || value.ActiveParameter != 0 {
+		t.Fatalf("Expected the TypeScript signature of value, got %+v", value)
+	}
+	if documentation, _ := value.Signatures[0].Documentation.(MarkupContent); !strings.Contains(documentation.Value, "Current value") || !strings.Contains(documentation.Value, "my/item/item.view.tree:2") {
+		t.Errorf("Unexpected documentation %+v", value.Signatures[0].Documentation)
+	}
+	
+	if count := help(4, 14); count == nil || count.Signatures[0].Label != "count(next?: number): number" {
+		t.Errorf("Expected the generated signature of count, got %+v", count)
+	}
+	row := help(5, 10)
+	if row == nil || row.Signatures[0].Label != "Row(id: string): $mol_view" || row.ActiveParameter != 0 || len(row.Signatures[0].Parameters) != 1 {
+		t.Errorf("Expected the keyed signature of Row, got %+v", row)
+	}
+	if sub := help(1, 6); sub != nil {
+		t.Errorf("Expected no signature for a plain property, got %+v", sub)
+	}
+	
+	// Properties of the document are located by its decoded path
+	spaced := "$my_app $mol_view\n\tcurrent? \\\n\tsub /\n\t\t<= Item $my_item\n\t\t\tvalue? <=> current? "
+	own := provider.ProvideSignatureHelp(&TextDocument{URI: pathToURI(filepath.Join(root, "my app", "app.view.tree")), Text: spaced}, Position{Line: 4, Character: 22})
+	if own == nil {
+		t.Fatal("Expected the signature of current")
+	}
+	if documentation, _ := own.Signatures[0].Documentation.(MarkupContent); !strings.Contains(documentation.Value, "my app/app.view.tree:2") {
+		t.Errorf("Expected the declaration in the document, got %+v", own.Signatures[0].Documentation)
+	}
+}
+
+func TestInlayHints(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"mol/view/view.view.tree": "$mol_view $mol_object\n\tsub /\n",
+		"my/base/base.view.tree":  "$my_base $mol_view\n\ttitle \\Hello\n\titems /string\n\t\t\\a\n",
+	})
+	scanner := NewProjectScanner(root)
+	if err := scanner.ScanProject(); err != nil {
+		t.Fatal(err)
+	}
+	provider := NewInlayHintProvider(scanner)
+	
+	app := "$my_app $my_base\n\ttitle ^\n\tbody /\n\t\t<= items\n\tcaption @ \\Save\n\tsub /\n\t\t<= Row $my_base\n\t\t\ttitle ^"
+	describe := func() string {
+		hints := provider.ProvideInlayHints(&TextDocument{URI: "file://" + filepath.Join(root, "my", "app", "app.view.tree"), Text: app}, Range{End: Position{Line: 100}})
+		var result []string
+		for _, hint := range hints {
+			result = append(result, fmt.Sprintf("%d:%d %s", hint.Position.Line, hint.Position.Character, hint.Label))
+		}
+		return strings.Join(result, "; ")
+	}
+	
+	want := `3:10 : Array<string>; 3:10 = /string …; 1:8 from $my_base; 1:8 = \Hello; 7:10 from $my_base; 7:10 = \Hello; 4:16 $my_app_caption`
+	if got := describe(); got != want {
+		t.Errorf("Unexpected hints:\nexpected %s\ngot      %s", want, got)
+	}
+	
+	settings := DefaultSettings()
+	settings.InlayHints.Types = false
+	settings.InlayHints.Defaults = false
+	scanner.SetSettings(settings)
+	if got := describe(); got != "1:8 from $my_base; 7:10 from $my_base; 4:16 $my_app_caption" {
+		t.Errorf("Expected disabled hint kinds to be left out, got %s", got)
+	}
+}
+
+func TestCodeLens(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"mol/view/view.view.tree": "$mol_view $mol_object\n\tsub /\n",
+		"my/base/base.view.tree":  "$my_base $mol_view\n\ttitle \\Hello\n\tsub /\n\t\t<= Label $mol_view\n\t\t\tsub /\n\t\t\t\t<= title\n",
+		"my/base/base.view.ts":    "namespace $.$$ {\n\texport class $my_base extends $.$my_base {\n\t\ttitle() {\n\t\t\treturn ''\n\t\t}\n\t\tsub() {\n\t\t\treturn [ this.title() ]\n\t\t}\n\t}\n}",
+		"my/app/app.view.tree":    "$my_app $my_base\n\ttitle \\App\n\tsub /\n\t\t<= Head $my_base\n\t\t\ttitle <= head \\Head\n",
+		"my/page/page.view.tree":  "$my_page $mol_view\n\titems /$my_base\n",
+		"my/util/util.ts":         "namespace $ {\n\texport function $my_util() {\n\t\treturn new $my_base\n\t}\n}",
+	})
+	scanner := NewProjectScanner(root)
+	if err := scanner.ScanProject(); err != nil {
+		t.Fatal(err)
+	}
+	provider := NewCodeLensProvider(scanner)
+	
+	content, err := os.ReadFile(filepath.Join(root, "my", "base", "base.view.tree"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lenses := provider.ProvideCodeLenses(&TextDocument{URI: "file://" + filepath.Join(root, "my", "base", "base.view.tree"), Text: string(content)})
+	
+	var result []string
+	for _, lens := range lenses {
+		if lens.Command != nil {
+			t.Errorf("Expected lenses to be resolved lazily, got %q", lens.Command.Title)
+		}
+		resolved := provider.ResolveCodeLens(lens, *lens.Data.(*CodeLensData))
+		result = append(result, fmt.Sprintf("%d %s", lens.Range.Start.Line, resolved.Command.Title))
+	}
+	
+	want := "0 4 references; 0 used by 2 components; 0 overridden in base.view.ts; 1 4 references; 1 overridden in base.view.ts; 2 1 reference; 2 overridden in base.view.ts"
+	if got := strings.Join(result, "; "); got != want {
+		t.Errorf("Unexpected lenses:\nexpected %s\ngot      %s", want, got)
+	}
+}
+
+func TestFoldingRanges(t *testing.T) {
+	provider := NewFoldingRangeProvider(NewProjectScanner(t.TempDir()))
+	content := "- Application shell\n\tshown on every page\n$my_app $mol_view\n\ttitle \\App\n\tsub /\n\t\t<= Head $mol_view\n\t\t\tsub /\n\t\t\t\t<= title\n\t\t<= Body $mol_view\n\ttext \\\n\t\t\\first line\n\t\t\\second line\n\tlabels *\n\t\tsave @ \\Save\n"
+	document := &TextDocument{URI: "file:///test/app.view.tree", Text: content}
+	
+	describe := func(ranges []FoldingRange) string {
+		var result []string
+		for _, folding := range ranges {
+			entry := fmt.Sprintf("%d-%d", folding.StartLine, folding.EndLine)
+			if folding.Kind != "" {
+				entry += " " + folding.Kind
+			}
+			if folding.StartCharacter != nil {
+				entry += fmt.Sprintf(" %d:%d", *folding.StartCharacter, *folding.EndCharacter)
+			}
+			result = append(result, entry)
+		}
+		return strings.Join(result, "; ")
+	}
+	
+	want := "0-1 comment; 2-13; 4-8; 5-7; 6-7; 9-11; 10-11; 12-13"
+	if got := describe(provider.ProvideFoldingRanges(document, true, 0)); got != want {
+		t.Errorf("Unexpected folding ranges:\nexpected %s\ngot      %s", want, got)
+	}
+	
+	if got := describe(provider.ProvideFoldingRanges(document, false, 2)); got != "0-1 comment 19:20; 2-13 17:14" {
+		t.Errorf("Expected characters and the range limit to apply, got %s", got)
+	}
+}
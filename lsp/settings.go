@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+const (
+	// SettingsSection is the configuration section requested from the client
+	SettingsSection = "viewTree"
+	// SettingsFileName is the project-local fallback for clients without configuration support
+	SettingsFileName = ".viewtreerc.json"
+
+	HoverVerbosityMinimal = "minimal"
+	HoverVerbosityNormal  = "normal"
+	HoverVerbosityVerbose = "verbose"
+
+	SnippetStyleSnippet = "snippet"
+	SnippetStylePlain   = "plain"
+
+	RuleSeverityOff = "off"
+)
+
+// Settings is the typed "viewTree" configuration section.
+type Settings struct {
+	ScanRoots  []string `json:"scanRoots"`  // extra roots to scan, relative to the workspace
+	Exclude    []string `json:"exclude"`    // glob patterns excluded from scanning
+	MaxTsFiles int      `json:"maxTsFiles"` // 0 or less means no limit
+	// RespectIgnoreFiles skips paths listed in .gitignore and .ignore files
+	RespectIgnoreFiles bool `json:"respectIgnoreFiles"`
+	// LibraryRoots are indexed read-only with lower priority than workspace
+	// sources. Relative paths and globs are resolved against the workspace.
+	LibraryRoots    []string           `json:"libraryRoots"`
+	DetectLibraries bool               `json:"detectLibraries"` // add the MAM mol root and node_modules/mol_*
+	MaxLibraryFiles int                `json:"maxLibraryFiles"` // 0 or less means no limit
+	Diagnostics     DiagnosticSettings `json:"diagnostics"`
+	Hover           HoverSettings      `json:"hover"`
+	Completion      CompletionSettings `json:"completion"`
+	InlayHints      InlayHintSettings  `json:"inlayHints"`
+}
+
+type DiagnosticSettings struct {
+	// Severity maps a rule code (e.g. "VT002") to "error", "warning", "information", "hint" or "off"
+	Severity map[string]string `json:"severity"`
+}
+
+type HoverSettings struct {
+	Verbosity string `json:"verbosity"` // "minimal", "normal" or "verbose"
+}
+
+type CompletionSettings struct {
+	SnippetStyle string `json:"snippetStyle"` // "snippet" or "plain"
+}
+
+// InlayHintSettings turns each kind of inlay hint on or off
+type InlayHintSettings struct {
+	Types      bool `json:"types"`      // inferred type after a bare binding
+	Overrides  bool `json:"overrides"`  // ancestor whose value a "^" keeps
+	LocaleKeys bool `json:"localeKeys"` // generated key after a "@" string
+	Defaults   bool `json:"defaults"`   // inherited default value
+}
+
+func DefaultSettings() *Settings {
+	return &Settings{
+		ScanRoots:          []string{},
+		Exclude:            []string{},
+		MaxTsFiles:         100,
+		RespectIgnoreFiles: true,
+		LibraryRoots:       []string{},
+		DetectLibraries:    false,
+		MaxLibraryFiles:    2000,
+		Diagnostics: DiagnosticSettings{
+			Severity: map[string]string{},
+		},
+		Hover: HoverSettings{
+			Verbosity: HoverVerbosityNormal,
+		},
+		Completion: CompletionSettings{
+			SnippetStyle: SnippetStyleSnippet,
+		},
+		InlayHints: InlayHintSettings{
+			Types:      true,
+			Overrides:  true,
+			LocaleKeys: true,
+			Defaults:   true,
+		},
+	}
+}
+
+// ParseSettings decodes a raw configuration value on top of the defaults.
+// A nil value yields the defaults.
+func ParseSettings(raw interface{}) (*Settings, error) {
+	settings := DefaultSettings()
+	if raw == nil {
+		return settings, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal settings: %w", err)
+	}
+
+	if err := json.Unmarshal(data, settings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+
+	settings.normalize()
+	return settings, nil
+}
+
+// LoadSettingsFile reads .viewtreerc.json from the workspace root.
+// A missing file yields the defaults.
+func LoadSettingsFile(workspaceRoot string) (*Settings, error) {
+	content, err := os.ReadFile(filepath.Join(workspaceRoot, SettingsFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return DefaultSettings(), nil
+		}
+		return nil, err
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", SettingsFileName, err)
+	}
+
+	return ParseSettings(raw)
+}
+
+// normalize replaces unknown or missing values with defaults
+func (s *Settings) normalize() {
+	if s.ScanRoots == nil {
+		s.ScanRoots = []string{}
+	}
+	if s.Exclude == nil {
+		s.Exclude = []string{}
+	}
+	if s.LibraryRoots == nil {
+		s.LibraryRoots = []string{}
+	}
+	if s.Diagnostics.Severity == nil {
+		s.Diagnostics.Severity = map[string]string{}
+	}
+
+	switch s.Hover.Verbosity {
+	case HoverVerbosityMinimal, HoverVerbosityNormal, HoverVerbosityVerbose:
+	default:
+		s.Hover.Verbosity = HoverVerbosityNormal
+	}
+
+	switch s.Completion.SnippetStyle {
+	case SnippetStyleSnippet, SnippetStylePlain:
+	default:
+		s.Completion.SnippetStyle = SnippetStyleSnippet
+	}
+}
+
+// ScanSettingsEqual reports whether both settings produce the same project scan
+func (s *Settings) ScanSettingsEqual(other *Settings) bool {
+	return reflect.DeepEqual(s.ScanRoots, other.ScanRoots) &&
+		reflect.DeepEqual(s.Exclude, other.Exclude) &&
+		s.MaxTsFiles == other.MaxTsFiles &&
+		s.RespectIgnoreFiles == other.RespectIgnoreFiles &&
+		reflect.DeepEqual(s.LibraryRoots, other.LibraryRoots) &&
+		s.DetectLibraries == other.DetectLibraries &&
+		s.MaxLibraryFiles == other.MaxLibraryFiles
+}
+
+// RuleSeverity returns the configured severity for a rule code.
+// The boolean is false when the rule is not configured.
+func (s *Settings) RuleSeverity(code string) (DiagnosticSeverity, bool) {
+	switch s.Diagnostics.Severity[code] {
+	case "error":
+		return DiagnosticSeverityError, true
+	case "warning":
+		return DiagnosticSeverityWarning, true
+	case "information", "info":
+		return DiagnosticSeverityInformation, true
+	case "hint":
+		return DiagnosticSeverityHint, true
+	default:
+		return 0, false
+	}
+}
+
+// IsRuleDisabled reports whether a rule code is turned off
+func (s *Settings) IsRuleDisabled(code string) bool {
+	return s.Diagnostics.Severity[code] == RuleSeverityOff
+}
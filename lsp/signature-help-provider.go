@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// SignatureHelpProvider shows the method a view.tree property compiles to.
+// "*" properties take a key and "?" properties accept a new value:
+// "Row*" is "Row(id: string): $mol_view" and "value?" is
+// "value(next?: string): string".
+type SignatureHelpProvider struct {
+	projectScanner *ProjectScanner
+	typeInferrer   *TypeInferrer
+}
+
+func NewSignatureHelpProvider(projectScanner *ProjectScanner) *SignatureHelpProvider {
+	return &SignatureHelpProvider{
+		projectScanner: projectScanner,
+		typeInferrer:   NewTypeInferrer(projectScanner),
+	}
+}
+
+// ProvideSignatureHelp returns the signature of the keyed or mutable
+// property written last before the cursor on its line
+func (sp *SignatureHelpProvider) ProvideSignatureHelp(document *TextDocument, position Position) *SignatureHelp {
+	tree := ParseTree(document.Text)
+	decls := BuildComponentDecls(tree, uriToPath(document.URI))
+	decl := ComponentAt(decls, position.Line)
+	if decl == nil {
+		return nil
+	}
+
+	var node *TreeNode
+	tree.Walk(func(candidate *TreeNode) bool {
+		if candidate.Line == position.Line && !candidate.IsData() && candidate.Range.Start.Character < position.Character &&
+			candidate != decl.Node && candidate != decl.BaseNode && isPropertyName(candidate.Name) {
+			node = candidate
+		}
+		return true
+	})
+	if node == nil || (node.Parent != nil && strings.HasPrefix(node.Parent.Name, "*")) {
+		return nil
+	}
+
+	inferrer := sp.typeInferrer.ForDocument(decls)
+	class := PropertyClass(decl, node)
+	name := propertyBaseName(node.Name)
+	keyed := strings.Contains(node.Name, "*")
+	mutable := strings.Contains(node.Name, "?")
+
+	var declaring *ComponentDecl
+	for _, ancestor := range inferrer.ClassChain(class) {
+		if ancestorDecl := inferrer.componentDecl(ancestor); ancestorDecl != nil {
+			if property := ancestorDecl.Property(name); property != nil {
+				keyed = keyed || property.Multi
+				mutable = mutable || property.Mutable
+				declaring = ancestorDecl
+				break
+			}
+		}
+	}
+
+	signature, tsDoc := sp.tsSignature(inferrer, class, name)
+	if signature == nil {
+		if !keyed && !mutable {
+			return nil
+		}
+		signature = sp.generatedSignature(inferrer, class, name, keyed, mutable)
+	}
+	if len(signature.Parameters) == 0 {
+		return nil
+	}
+
+	var documentation []string
+	if declaring != nil {
+		property := declaring.Property(name)
+		documentation = append(documentation, fmt.Sprintf("Property of `%s` (`%s:%d`)", declaring.Name, sp.relativePath(declaring.File), property.Node.Line+1))
+	}
+	if tsDoc != "" {
+		documentation = append(documentation, tsDoc)
+	}
+	if len(documentation) > 0 {
+		signature.Documentation = MarkupContent{Kind: MarkupKindMarkdown, Value: strings.Join(documentation, "\n\n")}
+	}
+
+	// The key is written right after "*", the new value after "?"
+	active := 0
+	star := strings.Index(node.Name, "*")
+	typingKey := star >= 0 && position.Character > node.Range.Start.Character+star &&
+		position.Character <= node.Range.End.Character
+	if mutable && !typingKey {
+		active = len(signature.Parameters) - 1
+	}
+
+	return &SignatureHelp{
+		Signatures:      []SignatureInformation{*signature},
+		ActiveSignature: 0,
+		ActiveParameter: active,
+	}
+}
+
+// tsSignature returns the signature of the TypeScript method overriding the
+// property, if it takes parameters, with its JSDoc
+func (sp *SignatureHelpProvider) tsSignature(inferrer *TypeInferrer, class, name string) (*SignatureInformation, string) {
+	for _, ancestor := range inferrer.ClassChain(class) {
+		tsClass := sp.projectScanner.GetTsClass(ancestor)
+		if tsClass == nil || tsClass.Methods[name] == nil {
+			continue
+		}
+		method := tsClass.Methods[name]
+		if strings.TrimSpace(method.Params) == "" {
+			return nil, ""
+		}
+
+		returnType := method.ReturnType
+		if returnType == "" {
+			returnType = sp.typeName(inferrer.PropertyType(class, name))
+		}
+		signature := &SignatureInformation{
+			Label: fmt.Sprintf("%s(%s): %s", name, method.Params, returnType),
+		}
+		for _, parameter := range splitParameters(method.Params) {
+			signature.Parameters = append(signature.Parameters, ParameterInformation{Label: parameter})
+		}
+		return signature, method.Doc
+	}
+	return nil, ""
+}
+
+// generatedSignature builds the signature $mol generates for the property
+func (sp *SignatureHelpProvider) generatedSignature(inferrer *TypeInferrer, class, name string, keyed, mutable bool) *SignatureInformation {
+	valueType := sp.typeName(inferrer.PropertyType(class, name))
+	signature := &SignatureInformation{}
+	if keyed {
+		signature.Parameters = append(signature.Parameters, ParameterInformation{
+			Label:         "id: string",
+			Documentation: "Key written after `*`",
+		})
+	}
+	if mutable {
+		signature.Parameters = append(signature.Parameters, ParameterInformation{
+			Label:         "next?: " + valueType,
+			Documentation: "New value written after `?`, the current value is returned without it",
+		})
+	}
+
+	labels := make([]string, len(signature.Parameters))
+	for i, parameter := range signature.Parameters {
+		labels[i] = parameter.Label
+	}
+	signature.Label = fmt.Sprintf("%s(%s): %s", name, strings.Join(labels, ", "), valueType)
+	return signature
+}
+
+func (sp *SignatureHelpProvider) typeName(valueType *ValueType) string {
+	if !valueType.IsKnown() {
+		return "any"
+	}
+	return valueType.String()
+}
+
+// splitParameters splits a TypeScript parameter list on top-level commas
+func splitParameters(params string) []string {
+	var parameters []string
+	depth, start := 0, 0
+	for i, char := range params {
+		switch char {
+		case '(', '[', '{', '<':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case '>':
+			// "=>" of a function type does not close a generic
+			if i == 0 || params[i-1] != '=' {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				parameters = append(parameters, strings.TrimSpace(params[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(params[start:]); last != "" {
+		parameters = append(parameters, last)
+	}
+	return parameters
+}
+
+func (sp *SignatureHelpProvider) relativePath(file string) string {
+	relative, err := filepath.Rel(sp.projectScanner.workspaceRoot, file)
+	if err != nil {
+		return file
+	}
+	return relative
+}
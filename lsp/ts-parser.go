@@ -0,0 +1,178 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	tsClassRegex  = regexp.MustCompile(`\bclass\s+(\$\w+)(?:\s+extends\s+([\w$.]+))?`)
+	tsMethodRegex = regexp.MustCompile(`^\s*(?:(?:export|public|protected|private|override|static|async|readonly|get|set)\s+)*(\w+)\s*(?:<[^>]*>)?\s*\(([^)]*)\)\s*(?::\s*([^{;]+?))?\s*[{;]`)
+	tsCallRegex   = regexp.MustCompile(`\bthis\.(\w+)\s*\(`)
+)
+
+// TsClass is a "$name" class declared in a TypeScript file, usually the
+// $.$$ behaviour of a view.tree component
+type TsClass struct {
+	Name    string
+	Base    string // e.g. "$.$my_app" or "$mol_view"
+	File    string
+	Range   Range
+	Doc     string
+	Methods map[string]*TsMethod
+}
+
+// TsMethod is a method or accessor of a TsClass
+type TsMethod struct {
+	Name       string
+	Params     string
+	ReturnType string // annotation as written, "" when inferred by TypeScript
+	Doc        string
+	Range      Range
+	Calls      []string // names of "this.x()" calls in the body
+	CallRanges []Range  // range of the name of each call, parallel to Calls
+}
+
+// ParseTsClasses extracts $ classes with their methods from TypeScript source.
+// It is line based and expects the usual one declaration per line layout.
+func ParseTsClasses(content, file string) []*TsClass {
+	var classes []*TsClass
+	var current *TsClass
+	var method *TsMethod
+	var doc []string
+	inDoc := false
+	classDepth := 0
+	methodDepth := 0
+	depth := 0
+
+	for lineIndex, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		// JSDoc blocks preceding a declaration
+		if strings.HasPrefix(trimmed, "/**") {
+			inDoc = true
+			doc = nil
+		}
+		if inDoc {
+			text := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(trimmed, "/**"), "*"))
+			text = strings.TrimSpace(strings.TrimSuffix(text, "*/"))
+			text = strings.TrimSuffix(text, "/")
+			if text != "" {
+				doc = append(doc, text)
+			}
+			if strings.Contains(trimmed, "*/") {
+				inDoc = false
+			}
+			continue
+		}
+
+		if match := tsClassRegex.FindStringSubmatchIndex(line); match != nil && current == nil {
+			current = &TsClass{
+				Name:    line[match[2]:match[3]],
+				File:    file,
+				Doc:     strings.Join(doc, "\n"),
+				Methods: make(map[string]*TsMethod),
+				Range: Range{
+					Start: Position{Line: lineIndex, Character: match[2]},
+					End:   Position{Line: lineIndex, Character: match[3]},
+				},
+			}
+			if match[4] >= 0 {
+				current.Base = line[match[4]:match[5]]
+			}
+			classes = append(classes, current)
+			classDepth = depth
+			doc = nil
+		} else if current != nil && method == nil && depth == classDepth+1 {
+			if match := tsMethodRegex.FindStringSubmatchIndex(line); match != nil && !isTsKeyword(line[match[2]:match[3]]) {
+				name := line[match[2]:match[3]]
+				method = &TsMethod{
+					Name:   name,
+					Params: strings.TrimSpace(line[match[4]:match[5]]),
+					Doc:    strings.Join(doc, "\n"),
+					Range: Range{
+						Start: Position{Line: lineIndex, Character: match[2]},
+						End:   Position{Line: lineIndex, Character: match[3]},
+					},
+				}
+				if match[6] >= 0 {
+					method.ReturnType = strings.TrimSpace(line[match[6]:match[7]])
+				}
+				if existing := current.Methods[name]; existing == nil || existing.ReturnType == "" {
+					current.Methods[name] = method
+				}
+				methodDepth = depth
+				if strings.HasSuffix(strings.TrimSpace(line[:match[1]]), ";") {
+					method = nil // declaration without a body
+				}
+			}
+		}
+		if trimmed != "" && !strings.HasPrefix(trimmed, "@") {
+			doc = nil
+		}
+
+		code := stripTsStrings(line)
+		if method != nil {
+			from := 0
+			for _, call := range tsCallRegex.FindAllStringSubmatch(code, -1) {
+				method.Calls = append(method.Calls, call[1])
+				callRange := method.Range
+				// Stripping strings shifts columns, so find the call in the line itself
+				if index := strings.Index(line[from:], "this."+call[1]); index >= 0 {
+					start := from + index + len("this.")
+					callRange = Range{
+						Start: Position{Line: lineIndex, Character: start},
+						End:   Position{Line: lineIndex, Character: start + len(call[1])},
+					}
+					from = start
+				}
+				method.CallRanges = append(method.CallRanges, callRange)
+			}
+		}
+
+		depth += strings.Count(code, "{") - strings.Count(code, "}")
+		if method != nil && depth <= methodDepth {
+			method = nil
+		}
+		if current != nil && depth <= classDepth && strings.Contains(code, "}") {
+			current = nil
+		}
+	}
+
+	return classes
+}
+
+func isTsKeyword(word string) bool {
+	switch word {
+	case "if", "for", "while", "switch", "catch", "return", "function", "constructor", "super", "new":
+		return true
+	}
+	return false
+}
+
+// stripTsStrings blanks out string literals and line comments so braces
+// inside them are not counted
+func stripTsStrings(line string) string {
+	var builder strings.Builder
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		char := line[i]
+		if quote != 0 {
+			if char == '\\' {
+				i++
+			} else if char == quote {
+				quote = 0
+			}
+			continue
+		}
+		if char == '/' && i+1 < len(line) && line[i+1] == '/' {
+			break
+		}
+		if char == '"' || char == '\'' || char == '`' {
+			quote = char
+			continue
+		}
+		builder.WriteByte(char)
+	}
+	return builder.String()
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"strings"
+)
+
+// TypeHierarchyProvider browses component inheritance. A component with a
+// $.$$ behaviour class appears twice: the class generated from view.tree and
+// the TypeScript class overriding it, which is what subclasses extend.
+type TypeHierarchyProvider struct {
+	projectScanner *ProjectScanner
+	typeInferrer   *TypeInferrer
+}
+
+func NewTypeHierarchyProvider(projectScanner *ProjectScanner) *TypeHierarchyProvider {
+	return &TypeHierarchyProvider{
+		projectScanner: projectScanner,
+		typeInferrer:   NewTypeInferrer(projectScanner),
+	}
+}
+
+// PrepareTypeHierarchy returns the component named at the position
+func (tp *TypeHierarchyProvider) PrepareTypeHierarchy(document *TextDocument, position Position) []TypeHierarchyItem {
+	items := []TypeHierarchyItem{}
+	tree := ParseTree(document.Text)
+	decls := BuildComponentDecls(tree, uriToPath(document.URI))
+	node := tree.NodeAt(position)
+	if node == nil || node.IsData() {
+		return items
+	}
+
+	name := strings.TrimLeft(node.Name, "/*")
+	if !strings.HasPrefix(name, "$") {
+		return items
+	}
+
+	inferrer := tp.typeInferrer.ForDocument(decls)
+	if decl := inferrer.componentDecl(name); decl != nil {
+		return append(items, tp.treeItem(decl))
+	}
+	if class := tp.projectScanner.GetTsClass(name); class != nil {
+		return append(items, tp.tsItem(class))
+	}
+	return items
+}
+
+// Supertypes returns the class the item extends
+func (tp *TypeHierarchyProvider) Supertypes(item TypeHierarchyItem) []TypeHierarchyItem {
+	items := []TypeHierarchyItem{}
+	if item.Data == nil {
+		return items
+	}
+	component := item.Data.Component
+
+	base := ""
+	if item.Data.Source == HierarchySourceTs {
+		class := tp.projectScanner.GetTsClass(component)
+		if class == nil {
+			return items
+		}
+		base = strings.TrimPrefix(class.Base, "$.")
+		if base == component {
+			// The $.$$ class extends the class generated from view.tree
+			if decl := tp.projectScanner.GetComponentDecl(component); decl != nil {
+				items = append(items, tp.treeItem(decl))
+			}
+			return items
+		}
+	} else if decl := tp.projectScanner.GetComponentDecl(component); decl != nil {
+		base = decl.Base
+	}
+
+	if base == "" {
+		return items
+	}
+	if baseItem := tp.classItem(base); baseItem != nil {
+		items = append(items, *baseItem)
+	}
+	return items
+}
+
+// Subtypes returns the classes extending the item: the $.$$ class overriding a
+// view.tree class, otherwise the components based on it
+func (tp *TypeHierarchyProvider) Subtypes(item TypeHierarchyItem) []TypeHierarchyItem {
+	items := []TypeHierarchyItem{}
+	if item.Data == nil {
+		return items
+	}
+	component := item.Data.Component
+
+	if item.Data.Source == HierarchySourceTree {
+		if class := tp.overrideClass(component); class != nil {
+			return append(items, tp.tsItem(class))
+		}
+	}
+
+	for _, subclass := range tp.typeInferrer.Subclasses(component) {
+		if decl := tp.projectScanner.GetComponentDecl(subclass); decl != nil {
+			items = append(items, tp.treeItem(decl))
+		} else if class := tp.projectScanner.GetTsClass(subclass); class != nil {
+			items = append(items, tp.tsItem(class))
+		}
+	}
+	return items
+}
+
+// classItem returns the class a "$name" base refers to at runtime: the $.$$
+// override when there is one
+func (tp *TypeHierarchyProvider) classItem(name string) *TypeHierarchyItem {
+	if class := tp.overrideClass(name); class != nil {
+		item := tp.tsItem(class)
+		return &item
+	}
+	if decl := tp.projectScanner.GetComponentDecl(name); decl != nil {
+		item := tp.treeItem(decl)
+		return &item
+	}
+	if class := tp.projectScanner.GetTsClass(name); class != nil {
+		item := tp.tsItem(class)
+		return &item
+	}
+	return nil
+}
+
+// overrideClass returns the $.$$ class extending the view.tree class of the
+// component, if any
+func (tp *TypeHierarchyProvider) overrideClass(component string) *TsClass {
+	class := tp.projectScanner.GetTsClass(component)
+	if class == nil || class.Base != "$."+component {
+		return nil
+	}
+	return class
+}
+
+func (tp *TypeHierarchyProvider) treeItem(decl *ComponentDecl) TypeHierarchyItem {
+	detail := "view.tree"
+	declRange := decl.Node.Range
+	if decl.BaseNode != nil {
+		detail += " extends " + decl.Base
+		declRange.End = decl.BaseNode.Range.End
+	}
+	return TypeHierarchyItem{
+		Name:           decl.Name,
+		Kind:           SymbolKindClass,
+		Detail:         detail,
+		URI:            pathToURI(decl.File),
+		Range:          declRange,
+		SelectionRange: decl.Node.Range,
+		Data:           &HierarchyData{Component: decl.Name, Source: HierarchySourceTree},
+	}
+}
+
+func (tp *TypeHierarchyProvider) tsItem(class *TsClass) TypeHierarchyItem {
+	detail := "TypeScript"
+	if class.Base == "$."+class.Name {
+		detail = "$.$$ override"
+	} else if class.Base != "" {
+		detail += " extends " + class.Base
+	}
+	return TypeHierarchyItem{
+		Name:           class.Name,
+		Kind:           SymbolKindClass,
+		Detail:         detail,
+		URI:            pathToURI(class.File),
+		Range:          class.Range,
+		SelectionRange: class.Range,
+		Data:           &HierarchyData{Component: class.Name, Source: HierarchySourceTs},
+	}
+}
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	TypeUnknown   = "unknown"
+	TypeAny       = "any"
+	TypeString    = "string"
+	TypeNumber    = "number"
+	TypeBoolean   = "boolean"
+	TypeNull      = "null"
+	TypeList      = "list"
+	TypeDict      = "dict"
+	TypeComponent = "component"
+)
+
+var numberLiteralRegex = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?$`)
+
+// ValueType is the inferred type of a view.tree value
+type ValueType struct {
+	Kind      string
+	Element   *ValueType // item type of lists and dictionaries
+	Component string     // class of component instances
+}
+
+var unknownType = &ValueType{Kind: TypeUnknown}
+
+func (t *ValueType) String() string {
+	switch t.Kind {
+	case TypeList:
+		return "Array<" + t.Element.String() + ">"
+	case TypeDict:
+		return "Dictionary<" + t.Element.String() + ">"
+	case TypeComponent:
+		return t.Component
+	default:
+		return t.Kind
+	}
+}
+
+// IsKnown reports whether the type carries any information
+func (t *ValueType) IsKnown() bool {
+	return t.Kind != TypeUnknown && t.Kind != TypeAny
+}
+
+// TypeInferrer infers property types from view.tree declarations, bindings and
+// TypeScript return type annotations across the project index
+type TypeInferrer struct {
+	projectScanner *ProjectScanner
+	// declarations of the edited document, newer than the index
+	document map[string]*ComponentDecl
+}
+
+func NewTypeInferrer(projectScanner *ProjectScanner) *TypeInferrer {
+	return &TypeInferrer{
+		projectScanner: projectScanner,
+	}
+}
+
+// ForDocument returns an inferrer that prefers the given declarations of an
+// open document over the indexed ones
+func (ti *TypeInferrer) ForDocument(decls []*ComponentDecl) *TypeInferrer {
+	document := make(map[string]*ComponentDecl, len(decls))
+	for _, decl := range decls {
+		document[decl.Name] = decl
+	}
+	return &TypeInferrer{
+		projectScanner: ti.projectScanner,
+		document:       document,
+	}
+}
+
+func (ti *TypeInferrer) componentDecl(class string) *ComponentDecl {
+	if decl := ti.document[class]; decl != nil {
+		return decl
+	}
+	return ti.projectScanner.GetComponentDecl(class)
+}
+
+func (ti *TypeInferrer) baseClass(class string) string {
+	if decl := ti.document[class]; decl != nil {
+		return decl.Base
+	}
+	return ti.projectScanner.BaseClass(class)
+}
+
+// ClassChain returns the class followed by its known base classes
+func (ti *TypeInferrer) ClassChain(class string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+
+	for ; class != "" && !seen[class]; class = ti.baseClass(class) {
+		seen[class] = true
+		chain = append(chain, class)
+	}
+	return chain
+}
+
+// PropertyType returns the type of a property of a component, looking through
+// its TypeScript class and its base classes
+func (ti *TypeInferrer) PropertyType(component, property string) *ValueType {
+	return ti.propertyType(component, propertyBaseName(property), make(map[string]bool))
+}
+
+// ValueType returns the type of a value node written inside a component
+func (ti *TypeInferrer) ValueType(component string, value *TreeNode) *ValueType {
+	return ti.valueType(component, value, make(map[string]bool))
+}
+
+// IsClassChainComplete reports whether every class in the chain is indexed
+// and the chain ends at a root class, so missing members are really missing
+func (ti *TypeInferrer) IsClassChainComplete(class string) bool {
+	chain := ti.ClassChain(class)
+	if len(chain) == 0 {
+		return false
+	}
+	for _, name := range chain {
+		if ti.componentDecl(name) == nil && ti.projectScanner.GetTsClass(name) == nil {
+			return false
+		}
+	}
+	// An unresolved "$" base or a cycle leaves the chain incomplete
+	return !strings.HasPrefix(ti.baseClass(chain[len(chain)-1]), "$")
+}
+
+func (ti *TypeInferrer) propertyType(component, property string, visiting map[string]bool) *ValueType {
+	key := component + "." + property
+	if visiting[key] {
+		return unknownType
+	}
+	visiting[key] = true
+
+	for _, class := range ti.ClassChain(component) {
+		if tsClass := ti.projectScanner.GetTsClass(class); tsClass != nil {
+			if method := tsClass.Methods[property]; method != nil && method.ReturnType != "" {
+				if tsType := ParseTsType(method.ReturnType); tsType.Kind != TypeUnknown {
+					return tsType
+				}
+			}
+		}
+
+		decl := ti.componentDecl(class)
+		if decl == nil {
+			continue
+		}
+		if propertyDecl := decl.Property(property); propertyDecl != nil {
+			return ti.declType(decl, propertyDecl, visiting)
+		}
+	}
+
+	return unknownType
+}
+
+func (ti *TypeInferrer) declType(decl *ComponentDecl, property *PropertyDecl, visiting map[string]bool) *ValueType {
+	// "=>" exports the type of the child property it reads
+	if property.Via == BindingOutput {
+		for _, binding := range decl.Bindings {
+			if binding.Kind == BindingOutput && binding.Source == property.Name && binding.Instance != nil {
+				return ti.propertyType(binding.Instance.Component, binding.Target, visiting)
+			}
+		}
+		return unknownType
+	}
+
+	if property.Value == nil {
+		return unknownType
+	}
+	return ti.valueType(decl.Name, property.Value, visiting)
+}
+
+func (ti *TypeInferrer) valueType(component string, value *TreeNode, visiting map[string]bool) *ValueType {
+	if value == nil {
+		return unknownType
+	}
+	if value.IsData() {
+		return &ValueType{Kind: TypeString}
+	}
+
+	name := value.Name
+	switch {
+	case name == "@":
+		return &ValueType{Kind: TypeString}
+	case name == "null":
+		return &ValueType{Kind: TypeNull}
+	case name == "true" || name == "false":
+		return &ValueType{Kind: TypeBoolean}
+	case isNumberLiteral(name):
+		return &ValueType{Kind: TypeNumber}
+	case name == BindingOneWay || name == BindingTwoWay:
+		source := value.First()
+		if source == nil {
+			return unknownType
+		}
+		return ti.propertyType(component, propertyBaseName(source.Name), visiting)
+	case name == BindingOverride:
+		// "^" keeps the value of the base class
+		if property := enclosingPropertyNode(value); property != nil {
+			if base := ti.baseClass(component); base != "" {
+				return ti.propertyType(base, propertyBaseName(property.Name), visiting)
+			}
+		}
+		return unknownType
+	case strings.HasPrefix(name, "/"):
+		element := ParseElementType(strings.TrimPrefix(name, "/"))
+		if element.Kind == TypeUnknown {
+			var items []*ValueType
+			for _, item := range value.Children {
+				if item.Name == BindingOverride {
+					continue
+				}
+				items = append(items, ti.valueType(component, item, visiting))
+			}
+			element = unifyTypes(items)
+		}
+		return &ValueType{Kind: TypeList, Element: element}
+	case strings.HasPrefix(name, "*"):
+		element := ParseElementType(strings.TrimPrefix(name, "*"))
+		if element.Kind == TypeUnknown {
+			var items []*ValueType
+			for _, key := range value.Children {
+				if key.Name == BindingOverride {
+					continue
+				}
+				items = append(items, ti.valueType(component, key.First(), visiting))
+			}
+			element = unifyTypes(items)
+		}
+		return &ValueType{Kind: TypeDict, Element: element}
+	case strings.HasPrefix(name, "$"):
+		return &ValueType{Kind: TypeComponent, Component: name}
+	}
+
+	return unknownType
+}
+
+// IsAssignable reports whether a value of type from fits where type to is
+// expected. Unknown types, "any" and null are always accepted.
+func (ti *TypeInferrer) IsAssignable(from, to *ValueType) bool {
+	if !from.IsKnown() || !to.IsKnown() || from.Kind == TypeNull || to.Kind == TypeNull {
+		return true
+	}
+	if from.Kind != to.Kind {
+		return false
+	}
+
+	switch from.Kind {
+	case TypeList, TypeDict:
+		return ti.IsAssignable(from.Element, to.Element)
+	case TypeComponent:
+		for _, class := range ti.ClassChain(from.Component) {
+			if class == to.Component {
+				return true
+			}
+		}
+		// Assume compatibility when the chain is not fully indexed
+		return !ti.IsClassChainComplete(from.Component)
+	}
+	return true
+}
+
+// ParseElementType parses the type after "/" or "*": "number", "$mol_view", ...
+func ParseElementType(name string) *ValueType {
+	switch {
+	case name == "":
+		return unknownType
+	case strings.HasPrefix(name, "$"):
+		return &ValueType{Kind: TypeComponent, Component: name}
+	}
+	return ParseTsType(name)
+}
+
+// ParseTsType converts a TypeScript type annotation into a ValueType
+func ParseTsType(annotation string) *ValueType {
+	annotation = strings.TrimSpace(annotation)
+	annotation = strings.TrimPrefix(annotation, "readonly ")
+
+	switch annotation {
+	case "string":
+		return &ValueType{Kind: TypeString}
+	case "number", "bigint":
+		return &ValueType{Kind: TypeNumber}
+	case "boolean":
+		return &ValueType{Kind: TypeBoolean}
+	case "null", "void", "undefined":
+		return &ValueType{Kind: TypeNull}
+	case "any", "unknown", "object":
+		return &ValueType{Kind: TypeAny}
+	}
+
+	if strings.HasSuffix(annotation, "[]") {
+		return &ValueType{Kind: TypeList, Element: ParseTsType(strings.TrimSuffix(annotation, "[]"))}
+	}
+	for _, prefix := range []string{"Array<", "ReadonlyArray<", "readonly Array<"} {
+		if strings.HasPrefix(annotation, prefix) && strings.HasSuffix(annotation, ">") {
+			return &ValueType{Kind: TypeList, Element: ParseTsType(annotation[len(prefix) : len(annotation)-1])}
+		}
+	}
+	if strings.HasPrefix(annotation, "Record<") && strings.HasSuffix(annotation, ">") {
+		args := annotation[len("Record<") : len(annotation)-1]
+		if comma := strings.LastIndex(args, ","); comma >= 0 {
+			return &ValueType{Kind: TypeDict, Element: ParseTsType(args[comma+1:])}
+		}
+	}
+
+	// "string | null" and the like keep the non-null part
+	if strings.Contains(annotation, "|") {
+		var parts []*ValueType
+		for _, part := range strings.Split(annotation, "|") {
+			if parsed := ParseTsType(part); parsed.Kind != TypeNull {
+				parts = append(parts, parsed)
+			}
+		}
+		return unifyTypes(parts)
+	}
+
+	if strings.HasPrefix(annotation, "$") && !strings.ContainsAny(annotation, "<>[]{}() ") {
+		return &ValueType{Kind: TypeComponent, Component: strings.TrimPrefix(annotation, "$.")}
+	}
+	return unknownType
+}
+
+// unifyTypes returns the common type of the items, "any" when they differ
+func unifyTypes(types []*ValueType) *ValueType {
+	var result *ValueType
+	for _, itemType := range types {
+		if itemType.Kind == TypeUnknown || itemType.Kind == TypeNull {
+			continue
+		}
+		if result == nil {
+			result = itemType
+			continue
+		}
+		if result.String() != itemType.String() {
+			return &ValueType{Kind: TypeAny}
+		}
+	}
+	if result == nil {
+		return unknownType
+	}
+	return result
+}
+
+func isNumberLiteral(word string) bool {
+	switch word {
+	case "NaN", "Infinity", "+Infinity", "-Infinity":
+		return true
+	}
+	return numberLiteralRegex.MatchString(word)
+}
+
+// enclosingPropertyNode returns the property node a value belongs to: the
+// nearest ancestor that is neither an operator nor a literal
+func enclosingPropertyNode(value *TreeNode) *TreeNode {
+	for node := value.Parent; node != nil && node.Parent != nil; node = node.Parent {
+		if node.IsData() || node.Name == BindingOneWay || node.Name == BindingTwoWay || node.Name == BindingOutput || node.Name == BindingOverride {
+			continue
+		}
+		return node
+	}
+	return nil
+}
+
+// HasProperty reports whether a class or one of its bases declares the
+// property in view.tree or TypeScript. complete is false when the class chain
+// is not fully indexed, in which case a missing property proves nothing.
+func (ti *TypeInferrer) HasProperty(class, property string) (found, complete bool) {
+	property = propertyBaseName(property)
+	for _, name := range ti.ClassChain(class) {
+		if decl := ti.componentDecl(name); decl != nil && decl.Property(property) != nil {
+			return true, true
+		}
+		if tsClass := ti.projectScanner.GetTsClass(name); tsClass != nil && tsClass.Methods[property] != nil {
+			return true, true
+		}
+	}
+	return false, ti.IsClassChainComplete(class)
+}
+
+// Subclasses returns the indexed components extending the class directly,
+// sorted by name
+func (ti *TypeInferrer) Subclasses(class string) []string {
+	return ti.SubclassIndex()[class]
+}
+
+// SubclassIndex maps every class to the indexed components extending it
+// directly, sorted by name. Walks over the hierarchy build it once instead of
+// calling Subclasses for each class.
+func (ti *TypeInferrer) SubclassIndex() map[string][]string {
+	candidates := make(map[string]bool)
+	for name := range ti.document {
+		candidates[name] = true
+	}
+	for _, scanner := range ti.projectScanner.visibleScanners() {
+		data := scanner.project()
+		data.mutex.RLock()
+		for name := range data.Declarations {
+			candidates[name] = true
+		}
+		for name := range data.TsClasses {
+			candidates[name] = true
+		}
+		data.mutex.RUnlock()
+	}
+
+	subclasses := make(map[string][]string)
+	for name := range candidates {
+		if base := ti.baseClass(name); base != "" && base != name {
+			subclasses[base] = append(subclasses[base], name)
+		}
+	}
+	for _, names := range subclasses {
+		sort.Strings(names)
+	}
+	return subclasses
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// UsageAnalyzer finds view.tree properties that nothing binds, overrides or
+// calls, and components that nothing extends, instantiates or mentions
+type UsageAnalyzer struct {
+	projectScanner *ProjectScanner
+
+	// Project indexes without the declarations of one file, by file. The
+	// open document is layered on top, so typing in it reuses the index.
+	cache      map[string]*cachedUsageIndex
+	cacheMutex sync.Mutex
+}
+
+func NewUsageAnalyzer(projectScanner *ProjectScanner) *UsageAnalyzer {
+	return &UsageAnalyzer{
+		projectScanner: projectScanner,
+		cache:          make(map[string]*cachedUsageIndex),
+	}
+}
+
+// usageCacheLimit is how many files keep a cached project index
+const usageCacheLimit = 8
+
+// cachedUsageIndex is a project index with the index revision of every
+// visible root at the time it was built
+type cachedUsageIndex struct {
+	index     *usageIndex
+	revisions []uint64
+}
+
+// usageIndex collects the references of every root visible to the scanner.
+// A document index looks up what it does not hold in its base.
+type usageIndex struct {
+	components map[string]bool            // components extended, instantiated or used from TS
+	overrides  map[string]map[string]bool // class -> properties set or bound by subclasses or instances
+	tsNames    map[string]bool            // methods declared or called in TypeScript
+	base       *usageIndex
+}
+
+func newUsageIndex(base *usageIndex) *usageIndex {
+	return &usageIndex{
+		components: make(map[string]bool),
+		overrides:  make(map[string]map[string]bool),
+		tsNames:    make(map[string]bool),
+		base:       base,
+	}
+}
+
+// documentIndex layers the declarations of the open document over the
+// cached index of the rest of the project
+func (ua *UsageAnalyzer) documentIndex(inferrer *TypeInferrer, document []*ComponentDecl, documentFile string) *usageIndex {
+	index := newUsageIndex(ua.projectIndex(documentFile))
+	for _, decl := range document {
+		index.addDecl(inferrer, decl)
+	}
+	return index
+}
+
+// projectIndex returns the index of the project without the declarations of
+// documentFile. It is rebuilt when another file changed since it was built;
+// updates of documentFile itself do not matter.
+func (ua *UsageAnalyzer) projectIndex(documentFile string) *usageIndex {
+	scanners := ua.projectScanner.visibleScanners()
+	revisions := make([]uint64, len(scanners))
+	files := make([]string, len(scanners))
+	for i, scanner := range scanners {
+		revisions[i], files[i] = scanner.indexRevision()
+	}
+
+	ua.cacheMutex.Lock()
+	defer ua.cacheMutex.Unlock()
+
+	if cached := ua.cache[documentFile]; cached != nil && len(cached.revisions) == len(revisions) {
+		valid := true
+		for i, revision := range revisions {
+			if revision != cached.revisions[i] && (documentFile == "" || revision != cached.revisions[i]+1 || files[i] != documentFile) {
+				valid = false
+				break
+			}
+		}
+		if valid {
+			cached.revisions = revisions
+			return cached.index
+		}
+	}
+
+	index := ua.buildIndex(NewTypeInferrer(ua.projectScanner), scanners, documentFile)
+	if len(ua.cache) >= usageCacheLimit {
+		ua.cache = make(map[string]*cachedUsageIndex)
+	}
+	ua.cache[documentFile] = &cachedUsageIndex{index: index, revisions: revisions}
+	return index
+}
+
+// buildIndex indexes the project, leaving out the declarations of documentFile
+func (ua *UsageAnalyzer) buildIndex(inferrer *TypeInferrer, scanners []*ProjectScanner, documentFile string) *usageIndex {
+	index := newUsageIndex(nil)
+
+	var decls []*ComponentDecl
+	for _, scanner := range scanners {
+		data := scanner.project()
+		data.mutex.RLock()
+		for _, decl := range data.Declarations {
+			if decl.File != documentFile {
+				decls = append(decls, decl)
+			}
+		}
+		for _, class := range data.TsClasses {
+			for name, method := range class.Methods {
+				index.tsNames[name] = true
+				for _, call := range method.Calls {
+					index.tsNames[call] = true
+				}
+			}
+		}
+		for filePath, components := range data.FileComponents {
+			if !strings.HasSuffix(filePath, ".ts") {
+				continue
+			}
+			for component := range components {
+				// The behaviour class of a component does not use it
+				if class := data.TsClasses[component]; class != nil && class.File == filePath {
+					continue
+				}
+				index.components[component] = true
+			}
+		}
+		data.mutex.RUnlock()
+	}
+
+	for _, decl := range decls {
+		index.addDecl(inferrer, decl)
+	}
+	return index
+}
+
+// addDecl records what a declaration extends, instantiates, overrides and binds
+func (index *usageIndex) addDecl(inferrer *TypeInferrer, decl *ComponentDecl) {
+	if decl.Base != "" {
+		index.components[decl.Base] = true
+	}
+	for _, class := range inferrer.ClassChain(decl.Base) {
+		for _, property := range decl.Properties {
+			if property.Via == "" {
+				index.addOverride(class, property.Name)
+			}
+		}
+		// Bindings of a subclass use the inherited properties
+		for _, binding := range decl.Bindings {
+			index.addOverride(class, binding.Source)
+		}
+	}
+
+	for _, instance := range decl.Instances {
+		index.components[instance.Component] = true
+		for _, class := range inferrer.ClassChain(instance.Component) {
+			for _, override := range instance.Overrides {
+				index.addOverride(class, override.Name)
+			}
+		}
+	}
+
+	// Typed lists and dictionaries such as "/$my_row"
+	decl.Node.Walk(func(node *TreeNode) bool {
+		if !node.IsData() && (strings.HasPrefix(node.Name, "/$") || strings.HasPrefix(node.Name, "*$")) {
+			index.components[node.Name[1:]] = true
+		}
+		return true
+	})
+}
+
+func (index *usageIndex) addOverride(class, property string) {
+	if index.overrides[class] == nil {
+		index.overrides[class] = make(map[string]bool)
+	}
+	index.overrides[class][property] = true
+}
+
+func (index *usageIndex) usesComponent(component string) bool {
+	return index.components[component] || (index.base != nil && index.base.usesComponent(component))
+}
+
+func (index *usageIndex) overridden(class, property string) bool {
+	return index.overrides[class][property] || (index.base != nil && index.base.overridden(class, property))
+}
+
+func (index *usageIndex) usedFromTs(name string) bool {
+	return index.tsNames[name] || (index.base != nil && index.base.usedFromTs(name))
+}
+
+// unusedProperties returns the top level properties a component introduces
+// that are never used. Overrides of inherited properties are always used, so
+// components with a partially indexed inheritance chain are skipped.
+func (ua *UsageAnalyzer) unusedProperties(inferrer *TypeInferrer, index *usageIndex, decl *ComponentDecl) []*PropertyDecl {
+	if decl.Base != "" && !inferrer.IsClassChainComplete(decl.Base) {
+		return nil
+	}
+
+	bound := make(map[string]bool)
+	for _, binding := range decl.Bindings {
+		bound[binding.Source] = true
+	}
+
+	var unused []*PropertyDecl
+	for _, property := range decl.Properties {
+		if property.Via != "" || bound[property.Name] || index.usedFromTs(property.Name) || index.overridden(decl.Name, property.Name) {
+			continue
+		}
+		if decl.Base != "" {
+			if found, _ := inferrer.HasProperty(decl.Base, property.Name); found {
+				continue
+			}
+		}
+		unused = append(unused, property)
+	}
+	return unused
+}
+
+// isDeadComponent reports whether nothing refers to a component. Components
+// next to an index.html are application entry points and never dead.
+func (ua *UsageAnalyzer) isDeadComponent(index *usageIndex, decl *ComponentDecl) bool {
+	if index.usesComponent(decl.Name) {
+		return false
+	}
+	if decl.File != "" {
+		if _, err := os.Stat(filepath.Join(filepath.Dir(decl.File), "index.html")); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// DocumentDiagnostics reports unused properties and dead components of a document
+func (ua *UsageAnalyzer) DocumentDiagnostics(decls []*ComponentDecl, documentFile string) []Diagnostic {
+	var diagnostics []Diagnostic
+	inferrer := NewTypeInferrer(ua.projectScanner).ForDocument(decls)
+	index := ua.documentIndex(inferrer, decls, documentFile)
+
+	for _, decl := range decls {
+		if ua.isDeadComponent(index, decl) {
+			diagnostics = append(diagnostics, Diagnostic{
+				Severity: DiagnosticSeverityHint,
+				Range:    decl.Node.Range,
+				Message:  "Component '" + decl.Name + "' is never used",
+				Code:     RuleDeadComponent,
+				Source:   "view.tree",
+				Tags:     []DiagnosticTag{DiagnosticTagUnnecessary},
+			})
+		}
+
+		for _, property := range ua.unusedProperties(inferrer, index, decl) {
+			diagnostics = append(diagnostics, Diagnostic{
+				Severity: DiagnosticSeverityHint,
+				Range:    property.Node.Range,
+				Message:  "Property '" + property.Name + "' is never bound, overridden or used from TypeScript",
+				Code:     RuleUnusedProperty,
+				Source:   "view.tree",
+				Tags:     []DiagnosticTag{DiagnosticTagUnnecessary},
+			})
+		}
+	}
+
+	return diagnostics
+}
+
+// DeadComponents lists the dead components of the workspace grouped by the
+// view.tree file declaring them, following the FileComponents index
+func (ua *UsageAnalyzer) DeadComponents() map[string][]*ComponentDecl {
+	index := ua.projectIndex("")
+	data := ua.projectScanner.project()
+
+	data.mutex.RLock()
+	defer data.mutex.RUnlock()
+
+	dead := make(map[string][]*ComponentDecl)
+	for filePath, components := range data.FileComponents {
+		if !strings.HasSuffix(filePath, ".view.tree") {
+			continue
+		}
+		for component := range components {
+			decl := data.Declarations[component]
+			if decl != nil && decl.File == filePath && ua.isDeadComponent(index, decl) {
+				dead[filePath] = append(dead[filePath], decl)
+			}
+		}
+		sort.Slice(dead[filePath], func(i, j int) bool {
+			return dead[filePath][i].Node.Line < dead[filePath][j].Node.Line
+		})
+	}
+	return dead
+}
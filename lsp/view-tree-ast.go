@@ -0,0 +1,209 @@
+package main
+
+import (
+	"strings"
+)
+
+// TreeNode is a node of the view.tree syntax tree. Every word of a line is a
+// node: the words following it on the same line and the lines indented below
+// the last word are its children. A "\" starts a data node that runs to the
+// end of the line.
+type TreeNode struct {
+	Name     string      `json:"name"`           // the word, or "\\" for data nodes
+	Data     string      `json:"data,omitempty"` // text of data nodes
+	Range    Range       `json:"range"`
+	Line     int         `json:"line"`
+	Parent   *TreeNode   `json:"-"`
+	Children []*TreeNode `json:"children,omitempty"`
+}
+
+// IsData reports whether the node is a "\" data node
+func (n *TreeNode) IsData() bool {
+	return n.Name == "\\"
+}
+
+// First returns the first child or nil
+func (n *TreeNode) First() *TreeNode {
+	if len(n.Children) == 0 {
+		return nil
+	}
+	return n.Children[0]
+}
+
+// Text returns the node as written, data nodes included
+func (n *TreeNode) Text() string {
+	if n.IsData() {
+		return "\\" + n.Data
+	}
+	return n.Name
+}
+
+// Walk visits the node and its descendants depth-first until fn returns false
+func (n *TreeNode) Walk(fn func(node *TreeNode) bool) bool {
+	if !fn(n) {
+		return false
+	}
+	for _, child := range n.Children {
+		if !child.Walk(fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// NodeAt returns the deepest node whose range contains the position
+func (n *TreeNode) NodeAt(position Position) *TreeNode {
+	var found *TreeNode
+	n.Walk(func(node *TreeNode) bool {
+		if node.Parent != nil && rangeContains(node.Range, position) {
+			found = node
+		}
+		return true
+	})
+	return found
+}
+
+// ParseTree builds the syntax tree of a view.tree document. The returned node
+// is a synthetic root whose children are the top level nodes. Comment nodes
+// ("-") and everything nested under them are skipped.
+func ParseTree(content string) *TreeNode {
+	root := &TreeNode{Line: -1}
+	parser := NewViewTreeParser()
+
+	// last node of the most recent line at each indentation level
+	var lastNodes []*TreeNode
+	commentIndent := -1
+
+	for lineIndex, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		indent := parser.getIndentLevel(line)
+		if commentIndent >= 0 && indent > commentIndent {
+			continue
+		}
+		commentIndent = -1
+
+		body := line[indent:]
+		if parser.isCommentNode(strings.TrimSpace(body)) {
+			commentIndent = indent
+			continue
+		}
+
+		// Attach the line to the last node of the closest shallower line
+		if indent > len(lastNodes) {
+			indent = len(lastNodes)
+		}
+		parent := root
+		if indent > 0 {
+			parent = lastNodes[indent-1]
+		}
+		lastNodes = lastNodes[:indent]
+
+		last := parseTreeLine(line, lineIndex, indent, parent)
+		if last != nil {
+			lastNodes = append(lastNodes, last)
+		} else {
+			lastNodes = append(lastNodes, parent)
+		}
+	}
+
+	return root
+}
+
+// parseTreeLine appends the nodes of one line as a chain under parent and
+// returns the last node of the chain
+func parseTreeLine(line string, lineIndex, start int, parent *TreeNode) *TreeNode {
+	var last *TreeNode
+	current := parent
+
+	for i := start; i < len(line); {
+		if line[i] == ' ' || line[i] == '\t' {
+			i++
+			continue
+		}
+
+		node := &TreeNode{Line: lineIndex, Parent: current}
+		if line[i] == '\\' {
+			node.Name = "\\"
+			node.Data = line[i+1:]
+			node.Range = Range{
+				Start: Position{Line: lineIndex, Character: i},
+				End:   Position{Line: lineIndex, Character: len(line)},
+			}
+			i = len(line)
+		} else {
+			end := i
+			for end < len(line) && line[end] != ' ' && line[end] != '\t' {
+				end++
+			}
+			node.Name = line[i:end]
+			node.Range = Range{
+				Start: Position{Line: lineIndex, Character: i},
+				End:   Position{Line: lineIndex, Character: end},
+			}
+			i = end
+		}
+
+		current.Children = append(current.Children, node)
+		current = node
+		last = node
+	}
+
+	return last
+}
+
+// insertionMarker stands in for the word being completed
+const insertionMarker = "\x00"
+
+// InsertionPoint parses the document as if the word before the cursor were
+// replaced by a placeholder, and returns the tree with the node the word
+// would be a child of. The parent is nil inside data and comments.
+func InsertionPoint(content string, position Position) (*TreeNode, *TreeNode) {
+	lines := strings.Split(content, "\n")
+	if position.Line >= len(lines) {
+		return ParseTree(content), nil
+	}
+
+	line := strings.TrimRight(lines[position.Line], "\r")
+	if position.Character < len(line) {
+		line = line[:position.Character]
+	}
+	for _, word := range strings.Fields(line) {
+		if strings.HasPrefix(word, "\\") {
+			// Data runs to the end of the line
+			return ParseTree(content), nil
+		}
+	}
+	end := len(line)
+	for end > 0 && line[end-1] != ' ' && line[end-1] != '\t' {
+		end--
+	}
+	lines[position.Line] = line[:end] + insertionMarker
+
+	tree := ParseTree(strings.Join(lines, "\n"))
+	var parent *TreeNode
+	tree.Walk(func(node *TreeNode) bool {
+		if node.Line == position.Line && node.Name == insertionMarker {
+			parent = node.Parent
+			return false
+		}
+		return true
+	})
+	return tree, parent
+}
+
+func rangeContains(r Range, position Position) bool {
+	if position.Line < r.Start.Line || position.Line > r.End.Line {
+		return false
+	}
+	if position.Line == r.Start.Line && position.Character < r.Start.Character {
+		return false
+	}
+	if position.Line == r.End.Line && position.Character > r.End.Character {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"strings"
+)
+
+const (
+	BindingOneWay   = "<="
+	BindingTwoWay   = "<=>"
+	BindingOutput   = "=>"
+	BindingOverride = "^"
+)
+
+// ComponentDecl is a root "$name $base" declaration with everything its body declares
+type ComponentDecl struct {
+	Name       string
+	Base       string
+	File       string
+	Node       *TreeNode // name node
+	BaseNode   *TreeNode // nil when the base class is missing
+	Properties []*PropertyDecl
+	Bindings   []*BindingDecl
+	Instances  []*InstanceDecl
+	properties map[string]*PropertyDecl
+}
+
+// PropertyDecl is a property of a component, declared either at the top level
+// of its body or on the right-hand side of a binding anywhere inside it
+type PropertyDecl struct {
+	Name      string // without "?" and "*" markers
+	Signature string // as written, e.g. "value?" or "Row*"
+	Multi     bool   // "*" properties take a key
+	Mutable   bool   // "?" properties accept a new value
+	Node      *TreeNode
+	Value     *TreeNode // default value, nil when none is given
+	Via       string    // binding operator that introduced the property, "" at the top level
+	Component *ComponentDecl
+}
+
+// BindingDecl is a "<=", "<=>" or "=>" link between two properties
+type BindingDecl struct {
+	Kind       string
+	Node       *TreeNode // operator node
+	Source     string    // owner property on the right-hand side
+	SourceNode *TreeNode
+	// Target is the property on the left-hand side. It is empty for list
+	// items and dictionary values.
+	Target     string
+	TargetNode *TreeNode
+	Instance   *InstanceDecl // set when Target overrides a property of a child instance
+}
+
+// InstanceDecl is a child component created inside a component body, e.g.
+// "<= Button $mol_button" with its property overrides
+type InstanceDecl struct {
+	Component string
+	Node      *TreeNode
+	Property  *PropertyDecl // owner property whose value creates the instance
+	Overrides []*OverrideDecl
+}
+
+// OverrideDecl sets a property of a child instance
+type OverrideDecl struct {
+	Name     string
+	Node     *TreeNode
+	Value    *TreeNode
+	Instance *InstanceDecl
+}
+
+// Property returns the declaration of an own property or nil
+func (c *ComponentDecl) Property(name string) *PropertyDecl {
+	return c.properties[propertyBaseName(name)]
+}
+
+// BuildComponentDecls extracts the component declarations of a parsed view.tree file
+func BuildComponentDecls(tree *TreeNode, file string) []*ComponentDecl {
+	var components []*ComponentDecl
+
+	for _, node := range tree.Children {
+		if !strings.HasPrefix(node.Name, "$") {
+			continue
+		}
+
+		component := &ComponentDecl{
+			Name:       node.Name,
+			File:       file,
+			Node:       node,
+			properties: make(map[string]*PropertyDecl),
+		}
+
+		body := node
+		if base := node.First(); base != nil && base.Line == node.Line {
+			component.Base = base.Name
+			component.BaseNode = base
+			body = base
+		}
+
+		for _, propertyNode := range body.Children {
+			if propertyNode.IsData() {
+				continue
+			}
+			property := component.declare(propertyNode, propertyNode.First(), "")
+			component.visitValue(propertyNode.First(), property, propertyNode, nil)
+		}
+
+		components = append(components, component)
+	}
+
+	return components
+}
+
+// declare registers an owner property. Repeated declarations only fill in a
+// missing default value.
+func (c *ComponentDecl) declare(node, value *TreeNode, via string) *PropertyDecl {
+	name := propertyBaseName(node.Name)
+	if existing := c.properties[name]; existing != nil {
+		if existing.Value == nil && value != nil {
+			existing.Value = value
+		}
+		return existing
+	}
+
+	property := &PropertyDecl{
+		Name:      name,
+		Signature: node.Name,
+		Multi:     strings.Contains(node.Name, "*"),
+		Mutable:   strings.Contains(node.Name, "?"),
+		Node:      node,
+		Value:     value,
+		Via:       via,
+		Component: c,
+	}
+	c.properties[name] = property
+	c.Properties = append(c.Properties, property)
+	return property
+}
+
+// visitValue walks a value node. target is the node on the left-hand side when
+// the value is assigned to a property, instance is set inside overrides.
+func (c *ComponentDecl) visitValue(value *TreeNode, owner *PropertyDecl, target *TreeNode, instance *InstanceDecl) {
+	if value == nil || value.IsData() {
+		return
+	}
+
+	switch {
+	case value.Name == BindingOneWay || value.Name == BindingTwoWay:
+		source := value.First()
+		if source == nil {
+			return
+		}
+		property := c.declare(source, source.First(), value.Name)
+		c.addBinding(value, source, target, instance)
+		c.visitValue(source.First(), property, source, nil)
+
+	case value.Name == BindingOutput:
+		source := value.First()
+		if source == nil || instance == nil {
+			return
+		}
+		c.declare(source, nil, value.Name)
+		c.addBinding(value, source, target, instance)
+
+	case strings.HasPrefix(value.Name, "/"):
+		for _, item := range value.Children {
+			c.visitValue(item, owner, nil, nil)
+		}
+
+	case strings.HasPrefix(value.Name, "*"):
+		for _, key := range value.Children {
+			c.visitValue(key.First(), owner, nil, nil)
+		}
+
+	case strings.HasPrefix(value.Name, "$"):
+		created := &InstanceDecl{
+			Component: value.Name,
+			Node:      value,
+			Property:  owner,
+		}
+		for _, overrideNode := range value.Children {
+			if overrideNode.IsData() {
+				continue
+			}
+			override := &OverrideDecl{
+				Name:     propertyBaseName(overrideNode.Name),
+				Node:     overrideNode,
+				Value:    overrideNode.First(),
+				Instance: created,
+			}
+			created.Overrides = append(created.Overrides, override)
+			c.visitValue(overrideNode.First(), owner, overrideNode, created)
+		}
+		c.Instances = append(c.Instances, created)
+	}
+}
+
+func (c *ComponentDecl) addBinding(operator, source, target *TreeNode, instance *InstanceDecl) {
+	binding := &BindingDecl{
+		Kind:       operator.Name,
+		Node:       operator,
+		Source:     propertyBaseName(source.Name),
+		SourceNode: source,
+		Instance:   instance,
+	}
+	if target != nil {
+		binding.Target = propertyBaseName(target.Name)
+		binding.TargetNode = target
+	}
+	c.Bindings = append(c.Bindings, binding)
+}
+
+// propertyBaseName strips the "?" and "*" markers: "value?next" -> "value"
+func propertyBaseName(name string) string {
+	if index := strings.IndexAny(name, "?*"); index >= 0 {
+		return name[:index]
+	}
+	return name
+}
+
+// ComponentAt returns the declaration whose body contains the line
+func ComponentAt(decls []*ComponentDecl, line int) *ComponentDecl {
+	var found *ComponentDecl
+	for _, decl := range decls {
+		if decl.Node.Line <= line {
+			found = decl
+		}
+	}
+	return found
+}
+
+// PropertyClass returns the class a property name node belongs to: the child
+// instance class for overrides like "title" under "<= Button $mol_button",
+// otherwise the declaring component
+func PropertyClass(decl *ComponentDecl, node *TreeNode) string {
+	if parent := node.Parent; parent != nil && parent != decl.Node && parent != decl.BaseNode &&
+		strings.HasPrefix(parent.Name, "$") {
+		return parent.Name
+	}
+	return decl.Name
+}
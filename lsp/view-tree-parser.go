@@ -31,9 +31,19 @@ type ParsedNode struct {
 	IndentLevel int    `json:"indentLevel"`
 }
 
+// ParsedComment is a "-" comment node. Lines nested under it belong to the
+// comment and are not parsed.
+type ParsedComment struct {
+	Text        string `json:"text"` // comment text without the leading "-"
+	Range       Range  `json:"range"`
+	Line        int    `json:"line"`
+	IndentLevel int    `json:"indentLevel"`
+}
+
 type ParseResult struct {
 	Components []ParsedComponent `json:"components"`
 	Nodes      []ParsedNode      `json:"nodes"`
+	Comments   []ParsedComment   `json:"comments"`
 	Errors     []ParseError      `json:"errors"`
 }
 
@@ -43,6 +53,11 @@ type ParseError struct {
 	Severity string             `json:"severity"` // "error", "warning", "info"
 }
 
+// ViewTreeParser is the line based scanner of view.tree files. It reports
+// syntax errors, comment lines and the word under the cursor, which work on
+// text that does not parse into a tree yet, e.g. a line being typed. The
+// structure of a document (components, properties, bindings) comes from
+// ParseTree and BuildComponentDecls; Parse only feeds the syntax checks.
 type ViewTreeParser struct {
 	lines []string
 }
@@ -57,12 +72,14 @@ func (vtp *ViewTreeParser) Parse(content string) ParseResult {
 	result := ParseResult{
 		Components: []ParsedComponent{},
 		Nodes:      []ParsedNode{},
+		Comments:   []ParsedComment{},
 		Errors:     []ParseError{},
 	}
 
 	// Stack to track components by indentation level
 	componentStack := make(map[int]*ParsedComponent)
 	var rootComponent *ParsedComponent
+	commentIndent := -1
 
 	for lineIndex, line := range vtp.lines {
 		if line == "" {
@@ -70,13 +87,31 @@ func (vtp *ViewTreeParser) Parse(content string) ParseResult {
 		}
 		trimmed := strings.TrimSpace(line)
 
-		// Skip empty lines and comments
-		if trimmed == "" || strings.HasPrefix(trimmed, "//") {
+		// Skip empty lines
+		if trimmed == "" {
 			continue
 		}
 
 		indentLevel := vtp.getIndentLevel(line)
 
+		// Skip lines nested under a comment node
+		if commentIndent >= 0 && indentLevel > commentIndent {
+			continue
+		}
+		commentIndent = -1
+
+		if vtp.isCommentNode(trimmed) {
+			start := strings.Index(line, "-")
+			result.Comments = append(result.Comments, ParsedComment{
+				Text:        strings.TrimSpace(trimmed[1:]),
+				Range:       vtp.getWordRange(lineIndex, start, trimmed),
+				Line:        lineIndex,
+				IndentLevel: indentLevel,
+			})
+			commentIndent = indentLevel
+			continue
+		}
+
 		// Root level component definition
 		if indentLevel == 0 && strings.HasPrefix(trimmed, "$") {
 			// Finish previous root component
@@ -376,6 +411,40 @@ func (vtp *ViewTreeParser) extractComponentReference(line string) string {
 	return ""
 }
 
+// CommentLines returns the indexes of all lines that belong to "-" comment
+// nodes, including the lines nested under them.
+func (vtp *ViewTreeParser) CommentLines(content string) map[int]bool {
+	commentLines := make(map[int]bool)
+	commentIndent := -1
+
+	for lineIndex, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		indentLevel := vtp.getIndentLevel(line)
+		if commentIndent >= 0 && indentLevel > commentIndent {
+			commentLines[lineIndex] = true
+			continue
+		}
+		commentIndent = -1
+
+		if vtp.isCommentNode(trimmed) {
+			commentLines[lineIndex] = true
+			commentIndent = indentLevel
+		}
+	}
+
+	return commentLines
+}
+
+// isCommentNode reports whether a trimmed line starts with the "-" comment node.
+// Values like "-Infinity" or "-5" are not comments.
+func (vtp *ViewTreeParser) isCommentNode(trimmed string) bool {
+	return trimmed == "-" || strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "-\t")
+}
+
 func (vtp *ViewTreeParser) getIndentLevel(line string) int {
 	indent := 0
 	for _, char := range line {
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Workspace is a single workspace folder with its own project index and providers
+type Workspace struct {
+	Folder                WorkspaceFolder
+	Root                  string
+	projectScanner        *ProjectScanner
+	definitionProvider    *DefinitionProvider
+	completionProvider    *CompletionProvider
+	hoverProvider         *HoverProvider
+	diagnosticProvider    *DiagnosticProvider
+	codeActionProvider    *CodeActionProvider
+	typeHierarchyProvider *TypeHierarchyProvider
+	callHierarchyProvider *CallHierarchyProvider
+	signatureHelpProvider *SignatureHelpProvider
+	inlayHintProvider     *InlayHintProvider
+	codeLensProvider      *CodeLensProvider
+	foldingRangeProvider  *FoldingRangeProvider
+}
+
+func NewWorkspace(folder WorkspaceFolder, root string) *Workspace {
+	projectScanner := NewProjectScanner(root)
+
+	return &Workspace{
+		Folder:                folder,
+		Root:                  root,
+		projectScanner:        projectScanner,
+		definitionProvider:    NewDefinitionProvider(projectScanner),
+		completionProvider:    NewCompletionProvider(projectScanner),
+		hoverProvider:         NewHoverProvider(projectScanner),
+		diagnosticProvider:    NewDiagnosticProvider(projectScanner),
+		codeActionProvider:    NewCodeActionProvider(projectScanner),
+		typeHierarchyProvider: NewTypeHierarchyProvider(projectScanner),
+		callHierarchyProvider: NewCallHierarchyProvider(projectScanner),
+		signatureHelpProvider: NewSignatureHelpProvider(projectScanner),
+		inlayHintProvider:     NewInlayHintProvider(projectScanner),
+		codeLensProvider:      NewCodeLensProvider(projectScanner),
+		foldingRangeProvider:  NewFoldingRangeProvider(projectScanner),
+	}
+}
+
+// WorkspaceManager keeps one workspace per folder and routes documents to them
+type WorkspaceManager struct {
+	workspaces []*Workspace
+	mutex      sync.RWMutex
+}
+
+func NewWorkspaceManager() *WorkspaceManager {
+	return &WorkspaceManager{}
+}
+
+// Add registers a workspace and links its scanner with the other roots.
+// It returns false when a workspace with the same root is registered.
+func (wm *WorkspaceManager) Add(workspace *Workspace) bool {
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+
+	for _, existing := range wm.workspaces {
+		if existing.Root == workspace.Root {
+			log.Printf("[view.tree] Workspace already registered: %s", workspace.Root)
+			return false
+		}
+	}
+
+	workspace.projectScanner.SetPeers(wm.scanners)
+	wm.workspaces = append(wm.workspaces, workspace)
+	return true
+}
+
+// Has reports whether a workspace with the given root is registered
+func (wm *WorkspaceManager) Has(root string) bool {
+	wm.mutex.RLock()
+	defer wm.mutex.RUnlock()
+
+	for _, workspace := range wm.workspaces {
+		if workspace.Root == root {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove unregisters the workspace with the given root and returns it
+func (wm *WorkspaceManager) Remove(root string) *Workspace {
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+
+	for i, workspace := range wm.workspaces {
+		if workspace.Root == root {
+			wm.workspaces = append(wm.workspaces[:i], wm.workspaces[i+1:]...)
+			workspace.projectScanner.SetPeers(nil)
+			return workspace
+		}
+	}
+
+	return nil
+}
+
+// All returns a snapshot of the registered workspaces
+func (wm *WorkspaceManager) All() []*Workspace {
+	wm.mutex.RLock()
+	defer wm.mutex.RUnlock()
+
+	workspaces := make([]*Workspace, len(wm.workspaces))
+	copy(workspaces, wm.workspaces)
+	return workspaces
+}
+
+// ForURI returns the workspace whose root contains the document. Documents
+// outside every root are served by the first workspace.
+func (wm *WorkspaceManager) ForURI(uri string) *Workspace {
+	wm.mutex.RLock()
+	defer wm.mutex.RUnlock()
+
+	if len(wm.workspaces) == 0 {
+		return nil
+	}
+
+	filePath := filepath.Clean(uriToPath(uri))
+	var best *Workspace
+	for _, workspace := range wm.workspaces {
+		root := filepath.Clean(workspace.Root)
+		if filePath != root && !strings.HasPrefix(filePath, root+string(filepath.Separator)) {
+			continue
+		}
+		if best == nil || len(root) > len(filepath.Clean(best.Root)) {
+			best = workspace
+		}
+	}
+
+	if best == nil {
+		return wm.workspaces[0]
+	}
+	return best
+}
+
+// scanners returns the project scanners of every workspace
+func (wm *WorkspaceManager) scanners() []*ProjectScanner {
+	wm.mutex.RLock()
+	defer wm.mutex.RUnlock()
+
+	scanners := make([]*ProjectScanner, 0, len(wm.workspaces))
+	for _, workspace := range wm.workspaces {
+		scanners = append(scanners, workspace.projectScanner)
+	}
+	return scanners
+}